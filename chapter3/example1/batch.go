@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// One line of the NDJSON output of the batch command
+type batchLine struct {
+	Index int             `json:"index"`
+	Query string          `json:"query"`
+	Movie *omdb.MovieInfo `json:"movie,omitempty"`
+	Error string          `json:"error,omitempty"`
+	Code  int             `json:"code,omitempty"`
+}
+
+// Look up the IMDb ids or titles of a file, one per line, and write one
+// JSON document per line. With -out the output is appended to the file and
+// the completed lines are recorded in a checkpoint file, so running the
+// same command again after an interruption resume where it stopped.
+// Transport errors are not recorded, they are reported on stderr instead of
+// the output and retried on resume. The index of a line is recorded after
+// the line is written, and the lines already in the output are skipped too,
+// so a crash between the two writes does not duplicate the line.
+func setupBatch(fs *flag.FlagSet) runFunc {
+	out := fs.String("out", "", "append the results to this file instead of stdout")
+	checkpoint := fs.String("checkpoint", "", "checkpoint file, default to <out>.checkpoint")
+	workers := fs.Int("workers", omdb.DefaultBatchWorkers, "number of concurrent lookups")
+	ordered := fs.Bool("ordered", false, "write the results in input order instead of completion order")
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) > 1 {
+			return usageError("expected at most one input file")
+		}
+		in := io.Reader(os.Stdin)
+		if len(args) == 1 && args[0] != "-" {
+			file, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			in = file
+		}
+
+		w := env.stdout
+		done := map[int]bool{}
+		var record io.Writer
+		written := map[int]bool{}
+		if *out != "" {
+			var err error
+			if written, err = loadOutput(*out); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(*out, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			w = file
+			if *checkpoint == "" {
+				*checkpoint = *out + ".checkpoint"
+			}
+		}
+		if *checkpoint != "" {
+			var err error
+			if done, err = loadCheckpoint(*checkpoint); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(*checkpoint, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			record = file
+		}
+
+		queries, readErr := omdb.ReadQueries(ctx, in)
+		results := env.client.Batch(ctx, queries, omdb.BatchOptions{
+			Workers: *workers,
+			Ordered: *ordered,
+			Skip:    func(index int) bool { return done[index] || written[index] },
+		})
+		encoder := json.NewEncoder(w)
+		failed := 0
+		for result := range results {
+			line := batchLine{Index: result.Index, Query: result.Query, Movie: result.Movie}
+			if result.Err != nil {
+				failed++
+				line.Error, line.Code = result.Err.Error(), exitCode(result.Err)
+			}
+			recorded := result.Err == nil || line.Code == exitNotFound
+			if record != nil && !recorded {
+				// Retried on resume, so not written to the output where it
+				// would be duplicated
+				fmt.Fprintf(env.stderr, "line %d: %s: %v\n", result.Index+1, result.Query, result.Err)
+				continue
+			}
+			if err := encoder.Encode(line); err != nil {
+				return err
+			}
+			if record != nil {
+				fmt.Fprintln(record, result.Index)
+			}
+		}
+		if err := readErr(); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if failed > 0 {
+			fmt.Fprintf(env.stderr, "%d lookups failed\n", failed)
+		}
+		return nil
+	}
+}
+
+// Read the indexes of the lines of an output file, a missing file mean none.
+// A last line cut by a crash is ended so the next line start on its own.
+func loadOutput(path string) (map[int]bool, error) {
+	written := map[int]bool{}
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return written, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		result := batchLine{Index: -1}
+		if json.Unmarshal([]byte(line), &result) == nil && result.Index >= 0 {
+			written[result.Index] = true
+		}
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		if _, err := file.WriteString("\n"); err != nil {
+			return nil, err
+		}
+	}
+	return written, nil
+}
+
+// Read the indexes of the completed lookups, a missing file mean none
+func loadCheckpoint(path string) (map[int]bool, error) {
+	done := map[int]bool{}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return done, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if index, err := strconv.Atoi(strings.TrimSpace(scanner.Text())); err == nil {
+			done[index] = true
+		}
+	}
+	return done, scanner.Err()
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/poster"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/provider"
+	"strconv"
+	"strings"
+)
+
+// Flags of the commands showing one movie to also download its poster
+type posterFlags struct {
+	dir    *string
+	thumbs *string
+}
+
+func newPosterFlags(fs *flag.FlagSet) *posterFlags {
+	return &posterFlags{
+		dir:    fs.String("poster", "", "download the poster under this directory"),
+		thumbs: fs.String("thumbs", "", "comma separated widths of the poster thumbnails, example: 100,300"),
+	}
+}
+
+// Download the poster if asked, a movie without poster is not an error
+func (pf *posterFlags) save(ctx context.Context, env *environment, mi *omdb.MovieInfo) error {
+	if *pf.dir == "" {
+		return nil
+	}
+	widths := []int{}
+	for _, s := range strings.Split(*pf.thumbs, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		width, err := strconv.Atoi(s)
+		if err != nil || width < 1 {
+			return usageError("invalid thumbnail width " + s)
+		}
+		widths = append(widths, width)
+	}
+	result, err := poster.New(env.client, *pf.dir, widths...).Download(ctx, mi)
+	if err == poster.ErrNoPoster {
+		fmt.Fprintln(env.stderr, "no poster for", mi.ImdbID)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	fmt.Fprintln(env.stderr, "poster:", result.Path)
+	for _, width := range widths {
+		fmt.Fprintln(env.stderr, "thumbnail:", result.Thumbnails[width])
+	}
+	return nil
+}
+
+func setupGet(fs *flag.FlagSet) runFunc {
+	posterFlags := newPosterFlags(fs)
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) != 1 {
+			return usageError("expected one IMDb id")
+		}
+		mi, err := lookup(ctx, env, args[0])
+		if err != nil {
+			return err
+		}
+		if err := writeMovie(env, mi); err != nil {
+			return err
+		}
+		return posterFlags.save(ctx, env, mi)
+	}
+}
+
+func setupFind(fs *flag.FlagSet) runFunc {
+	year := fs.String("year", "", "year of release")
+	kind := fs.String("type", "", "movie, series or episode")
+	plot := fs.String("plot", "", "plot length: short or full")
+	exact := fs.Bool("exact", false, "only the exact title, no search for close titles")
+	posterFlags := newPosterFlags(fs)
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) != 1 {
+			return usageError("expected one title, quote titles with spaces")
+		}
+		var mi *omdb.MovieInfo
+		var err error
+		q := omdb.TitleQuery{Title: args[0], Year: *year, Type: *kind, Plot: *plot}
+		if *exact {
+			mi, err = env.provider.FindByTitle(ctx, q)
+		} else {
+			mi, err = resolve(ctx, env, args[0], *year, *kind, *plot)
+			if _, ok := env.provider.(*provider.Composite); ok && exitCode(err) == exitTransport {
+				fmt.Fprintln(env.stderr, "warning:", err)
+				mi, err = env.provider.FindByTitle(ctx, q)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if err := writeMovie(env, mi); err != nil {
+			return err
+		}
+		return posterFlags.save(ctx, env, mi)
+	}
+}
+
+// Look up the id with the provider, with a composite provider the backends
+// that failed are reported on stderr
+func lookup(ctx context.Context, env *environment, id string) (*omdb.MovieInfo, error) {
+	composite, ok := env.provider.(*provider.Composite)
+	if !ok {
+		return env.provider.GetByID(ctx, id)
+	}
+	merged, err := composite.Lookup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for name, err := range merged.Errors {
+		fmt.Fprintf(env.stderr, "warning: %s: %v\n", name, err)
+	}
+	return merged.Movie, nil
+}
+
+// Look up the title allowing partial or misspelled titles, the match is
+// reported on stderr when the title is not exact
+func resolve(ctx context.Context, env *environment, title, year, kind, plot string) (*omdb.MovieInfo, error) {
+	opts := omdb.ResolveOptions{Type: kind, Plot: plot}
+	if year != "" {
+		y, err := strconv.Atoi(year)
+		if err != nil {
+			return nil, usageError("invalid year " + year)
+		}
+		opts.Year = y
+	}
+	res, err := env.client.Resolve(ctx, title, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Exact {
+		fmt.Fprintf(env.stderr, "%q matched %s (%s) with confidence %.2f\n", title, res.Movie.Title, res.Movie.Year, res.Confidence)
+	}
+	return res.Movie, nil
+}
+
+func setupSearch(fs *flag.FlagSet) runFunc {
+	year := fs.String("year", "", "year of release")
+	kind := fs.String("type", "", "movie, series or episode")
+	page := fs.Int("page", 1, "page of results to show")
+	allPages := fs.Bool("all-pages", false, "show the results of every page")
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) != 1 {
+			return usageError("expected one query, quote queries with spaces")
+		}
+		q := omdb.SearchQuery{Query: args[0], Type: *kind, Year: *year, Page: *page}
+		if *allPages {
+			results, err := env.client.SearchAll(ctx, q)
+			if err != nil {
+				return err
+			}
+			return writeSearchResults(env, results)
+		}
+		results, err := env.provider.Search(ctx, q)
+		if err != nil {
+			return err
+		}
+		return writeSearchResults(env, results)
+	}
+}
+
+func setupSeason(fs *flag.FlagSet) runFunc {
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) != 2 {
+			return usageError("expected an IMDb id and a season number")
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return usageError("invalid season number " + args[1])
+		}
+		season, err := env.client.GetSeason(ctx, args[0], n)
+		if err != nil {
+			return err
+		}
+		return writeSeason(env, season)
+	}
+}
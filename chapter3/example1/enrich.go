@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/enrich"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Append OMDb columns to a CSV of IMDb ids or titles. The rows that could
+// not be resolved are listed on stderr, or in the --report CSV file.
+func setupEnrich(fs *flag.FlagSet) runFunc {
+	out := fs.String("out", "", "write the CSV to this file instead of stdout")
+	column := fs.String("column", "", "column of the IMDb ids or titles, detected by default")
+	fields := fs.String("fields", strings.Join(enrich.DefaultFields, ","), "comma separated OMDb fields to append")
+	workers := fs.Int("workers", omdb.DefaultBatchWorkers, "number of concurrent lookups")
+	reportFile := fs.String("report", "", "write the unresolved rows to this CSV file")
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) > 1 {
+			return usageError("expected at most one input file")
+		}
+		for _, field := range splitList(*fields) {
+			if !omdb.IsField(field) {
+				return usageError("unknown field " + field)
+			}
+		}
+		in := io.Reader(os.Stdin)
+		if len(args) == 1 && args[0] != "-" {
+			file, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			in = file
+		}
+		w := env.stdout
+		if *out != "" {
+			file, err := os.Create(*out)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			w = file
+		}
+		// The same movie often appear on several rows
+		if env.client.Cache == nil {
+			env.client.Cache = omdb.NewLRUCache(1000)
+		}
+
+		report, err := enrich.Enrich(ctx, env.client, in, w, enrich.Options{
+			Column:  *column,
+			Fields:  splitList(*fields),
+			Workers: *workers,
+		})
+		if err != nil {
+			return err
+		}
+		kind := "titles"
+		if report.ByID {
+			kind = "IMDb ids"
+		}
+		fmt.Fprintf(env.stderr, "column %q of %s: %d rows, %d resolved, %d unresolved\n",
+			report.Column, kind, report.Rows, report.Resolved, len(report.Unresolved))
+		if *reportFile != "" {
+			return writeUnresolved(*reportFile, report.Unresolved)
+		}
+		for _, u := range report.Unresolved {
+			fmt.Fprintf(env.stderr, "line %d: %q: %v\n", u.Line, u.Query, u.Err)
+		}
+		return nil
+	}
+}
+
+func writeUnresolved(path string, unresolved []enrich.Unresolved) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"line", "query", "error", "code"})
+	for _, u := range unresolved {
+		writer.Write([]string{strconv.Itoa(u.Line), u.Query, u.Err.Error(), strconv.Itoa(exitCode(u.Err))})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
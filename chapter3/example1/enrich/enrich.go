@@ -0,0 +1,224 @@
+// Package enrich append OMDb columns to a CSV of movies, like the exports
+// of IMDb or Letterboxd.
+package enrich
+
+/*
+The column holding the movies is found from its name, "Const" in the IMDb
+exports and "Name" in the Letterboxd ones, or else from its content. A
+column of IMDb ids is looked up by id, a column of titles by title with the
+year of the row when there is a year column, allowing partial or misspelled
+titles (see omdb.Client.Resolve).
+
+Every row is written back, the rows that could not be resolved with empty
+OMDb columns, and listed in the Report.
+*/
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Columns appended when Options.Fields is empty
+var DefaultFields = []string{"imdbID", "Title", "Year", "Type", "Genre", "Director", "Runtime", "imdbRating", "imdbVotes", "Metascore"}
+
+// Names of the columns detected as IMDb ids, titles and years, lower cased
+var (
+	idColumns    = []string{"imdbid", "const", "tconst", "imdb id", "imdb_id", "id"}
+	titleColumns = []string{"title", "name", "film", "movie", "original title", "primarytitle"}
+	yearColumns  = []string{"year", "release year", "startyear"}
+)
+
+type Options struct {
+	// Name of the column of IMDb ids or titles, detected when empty
+	Column string
+	// OMDb fields appended to every row, see omdb.FieldNames
+	Fields []string
+	// Number of concurrent lookups, omdb.DefaultBatchWorkers when zero
+	Workers int
+}
+
+// A row that could not be resolved. Line is the number of the record,
+// counting the header as 1 and leaving out the blank lines.
+type Unresolved struct {
+	Line  int
+	Query string
+	Err   error
+}
+
+type Report struct {
+	// Header of the column used and whether it hold ids or titles
+	Column string
+	ByID   bool
+	Rows   int
+	// Number of rows resolved
+	Resolved   int
+	Unresolved []Unresolved
+}
+
+// Read the CSV from r, look up every row and write the CSV with the OMDb
+// fields appended to w. The returned error is about the CSV or the
+// context, a lookup error only make the row unresolved.
+func Enrich(ctx context.Context, client *omdb.Client, r io.Reader, w io.Writer, opts Options) (*Report, error) {
+	names := opts.Fields
+	if len(names) == 0 {
+		names = DefaultFields
+	}
+	fields := make([]string, len(names))
+	for i, name := range names {
+		field, ok := omdb.CanonicalField(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("enrich: unknown field %q", name)
+		}
+		fields[i] = field
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("enrich: empty CSV")
+	}
+	header, rows := records[0], records[1:]
+	column, byID, err := DetectColumn(header, rows, opts.Column)
+	if err != nil {
+		return nil, err
+	}
+	year := findColumn(header, yearColumns)
+	report := &Report{Column: header[column], ByID: byID, Rows: len(rows)}
+
+	queries := make(chan string)
+	go func() {
+		defer close(queries)
+		for _, row := range rows {
+			select {
+			case queries <- cell(row, column):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	results := client.Batch(ctx, queries, omdb.BatchOptions{
+		Workers: opts.Workers,
+		Ordered: true,
+		Lookup: func(ctx context.Context, index int, query string) (*omdb.MovieInfo, error) {
+			if query == "" {
+				return nil, errors.New("empty cell")
+			}
+			if byID {
+				return client.SearchById(ctx, query)
+			}
+			q := omdb.ResolveOptions{}
+			q.Year, _ = strconv.Atoi(cell(rows[index], year))
+			res, err := client.Resolve(ctx, query, q)
+			if err != nil {
+				return nil, err
+			}
+			return res.Movie, nil
+		},
+	})
+
+	writer := csv.NewWriter(w)
+	writer.Write(append(append([]string{}, header...), columnNames(header, fields)...))
+	for result := range results {
+		values := make([]string, len(fields))
+		if result.Err != nil {
+			report.Unresolved = append(report.Unresolved, Unresolved{Line: result.Index + 2, Query: result.Query, Err: result.Err})
+		} else {
+			report.Resolved++
+			values = result.Movie.Fields(fields)
+		}
+		// Pad the short rows so the values land under their own header
+		row := rows[result.Index]
+		for len(row) < len(header) {
+			row = append(row, "")
+		}
+		writer.Write(append(row, values...))
+	}
+	writer.Flush()
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+	return report, writer.Error()
+}
+
+// Return the index of the column of IMDb ids or titles and whether it hold
+// ids. The column is the one named name when not empty, else a column with
+// a known name, else the first one where most values look like IMDb ids.
+func DetectColumn(header []string, rows [][]string, name string) (int, bool, error) {
+	column := -1
+	if name != "" {
+		column = findColumn(header, []string{strings.ToLower(name)})
+		if column < 0 {
+			return 0, false, fmt.Errorf("enrich: no column %q", name)
+		}
+		return column, mostlyIDs(rows, column), nil
+	}
+	if column = findColumn(header, idColumns); column >= 0 && mostlyIDs(rows, column) {
+		return column, true, nil
+	}
+	for i := range header {
+		if mostlyIDs(rows, i) {
+			return i, true, nil
+		}
+	}
+	if column = findColumn(header, titleColumns); column >= 0 {
+		return column, false, nil
+	}
+	return 0, false, errors.New("enrich: no column of IMDb ids or titles, name it with Options.Column")
+}
+
+// Index of the first header matching one of the names, ignoring the case, or -1
+func findColumn(header []string, names []string) int {
+	for _, name := range names {
+		for i, h := range header {
+			if strings.ToLower(strings.TrimSpace(h)) == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Report if more than half of the non empty cells of the column are IMDb ids
+func mostlyIDs(rows [][]string, column int) bool {
+	ids, values := 0, 0
+	for _, row := range rows {
+		value := cell(row, column)
+		if value == "" {
+			continue
+		}
+		values++
+		if omdb.IsImdbID(value) {
+			ids++
+		}
+	}
+	return values > 0 && ids*2 > values
+}
+
+func cell(row []string, column int) string {
+	if column < 0 || column >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[column])
+}
+
+// Header of the appended columns, "OMDb Title" when the CSV already has a Title column
+func columnNames(header, fields []string) []string {
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field
+		if findColumn(header, []string{strings.ToLower(field)}) >= 0 {
+			names[i] = "OMDb " + field
+		}
+	}
+	return names
+}
@@ -0,0 +1,99 @@
+package enrich
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb/omdbtest"
+	"strings"
+	"testing"
+)
+
+func TestEnrich(t *testing.T) {
+	srv := omdbtest.MustNewServer(omdbtest.FixtureDir())
+	defer srv.Close()
+	client := omdb.NewClient(srv.URL, srv.Client(), omdbtest.APIKey, "")
+
+	tests := []struct {
+		name   string
+		in     string
+		byID   bool
+		column string
+		want   [][]string
+	}{
+		{
+			"imdb export",
+			"Const,Your Rating,Title,Year\ntt1375666,9,Inception,2010\ntt0000001,5,Unknown,1900\n",
+			true, "Const",
+			[][]string{
+				{"Const", "Your Rating", "Title", "Year", "imdbID", "OMDb Title", "Director"},
+				{"tt1375666", "9", "Inception", "2010", "tt1375666", "Inception", "Christopher Nolan"},
+				{"tt0000001", "5", "Unknown", "1900", "", "", ""},
+			},
+		},
+		{
+			"ragged row",
+			"Const,Your Rating,Title,Year\ntt1375666,9\ntt0468569\n",
+			true, "Const",
+			[][]string{
+				{"Const", "Your Rating", "Title", "Year", "imdbID", "OMDb Title", "Director"},
+				{"tt1375666", "9", "", "", "tt1375666", "Inception", "Christopher Nolan"},
+				{"tt0468569", "", "", "", "tt0468569", "The Dark Knight", "Christopher Nolan"},
+			},
+		},
+		{
+			"letterboxd export",
+			"Date,Name,Year,Letterboxd URI\n2020-01-01,the dark knigth,2008,https://boxd.it/2a\n2020-01-02,Guardians of the Galaxy,2017,https://boxd.it/2b\n",
+			false, "Name",
+			[][]string{
+				{"Date", "Name", "Year", "Letterboxd URI", "imdbID", "Title", "Director"},
+				{"2020-01-01", "the dark knigth", "2008", "https://boxd.it/2a", "tt0468569", "The Dark Knight", "Christopher Nolan"},
+				{"2020-01-02", "Guardians of the Galaxy", "2017", "https://boxd.it/2b", "tt3896198", "Guardians of the Galaxy Vol. 2", "James Gunn"},
+			},
+		},
+	}
+	for _, test := range tests {
+		out := &bytes.Buffer{}
+		report, err := Enrich(context.Background(), client, strings.NewReader(test.in), out, Options{Fields: []string{"imdbid", "Title", "Director"}, Workers: 2})
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if report.Column != test.column || report.ByID != test.byID || report.Rows != 2 {
+			t.Errorf("%s: got report %+v", test.name, report)
+		}
+		got, _ := csv.NewReader(out).ReadAll()
+		for i := range test.want {
+			if i >= len(got) || strings.Join(got[i], "|") != strings.Join(test.want[i], "|") {
+				t.Errorf("%s: got\n%v\nwant\n%v", test.name, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestUnresolved(t *testing.T) {
+	srv := omdbtest.MustNewServer(omdbtest.FixtureDir())
+	defer srv.Close()
+	client := omdb.NewClient(srv.URL, srv.Client(), omdbtest.APIKey, "")
+
+	in := "id\ntt1375666\n\ntt0000001\n"
+	report, err := Enrich(context.Background(), client, strings.NewReader(in), &bytes.Buffer{}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Resolved != 1 || len(report.Unresolved) != 1 {
+		t.Fatalf("got report %+v", report)
+	}
+	if u := report.Unresolved[0]; u.Line != 3 || u.Query != "tt0000001" || !errors.Is(u.Err, omdb.ErrNotFound) {
+		t.Errorf("got unresolved %+v", u)
+	}
+
+	if _, err := Enrich(context.Background(), client, strings.NewReader("a,b\n1,2\n"), &bytes.Buffer{}, Options{}); err == nil {
+		t.Error("no movie column: got no error")
+	}
+	if _, err := Enrich(context.Background(), client, strings.NewReader(in), &bytes.Buffer{}, Options{Fields: []string{"Budget"}}); err == nil {
+		t.Error("unknown field: got no error")
+	}
+}
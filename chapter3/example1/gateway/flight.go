@@ -0,0 +1,57 @@
+package gateway
+
+import (
+	"errors"
+	"sync"
+)
+
+// Error of the caller and the waiters of a call whose fn panicked
+var errCallPanicked = errors.New("gateway: lookup panicked")
+
+// In flight call of a flightGroup
+type call struct {
+	wg    sync.WaitGroup
+	value interface{}
+	err   error
+}
+
+// Coalesce identical concurrent lookups: while a call for a key is
+// running, the other callers for the same key wait for its result
+// instead of sending their own request.
+type flightGroup struct {
+	mutex sync.Mutex
+	calls map[string]*call
+}
+
+// Run fn once for all the concurrent callers of the key, shared report if
+// the result came from the call of another caller.
+func (g *flightGroup) Do(key string, fn func() (interface{}, error)) (value interface{}, err error, shared bool) {
+	g.mutex.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*call{}
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mutex.Unlock()
+		c.wg.Wait()
+		return c.value, c.err, true
+	}
+	c := &call{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mutex.Unlock()
+
+	// A panic of fn is recovered, the caller and the waiters get
+	// errCallPanicked instead of the panic crashing the gateway
+	defer func() {
+		if recover() != nil {
+			c.value, c.err = nil, errCallPanicked
+		}
+		g.mutex.Lock()
+		delete(g.calls, key)
+		g.mutex.Unlock()
+		c.wg.Done()
+		value, err, shared = c.value, c.err, false
+	}()
+	c.value, c.err = fn()
+	return c.value, c.err, false
+}
@@ -0,0 +1,236 @@
+// Package gateway serve movie metadata over a JSON API backed by one shared
+// omdb.Client, so the API key, the cache and the quota live in one place.
+package gateway
+
+/*
+Routes:
+
+	GET /movies/{imdbID}
+	GET /movies?title=&year=&type=
+	GET /search?q=&type=&year=&page=
+	GET /stats (counters, cache and API keys usage)
+	GET /health
+
+Errors are answered with the matching HTTP status and the body:
+
+	{"error": {"code": "not_found", "message": "Movie not found!"}}
+*/
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Time allowed to an upstream lookup when Server.Timeout is zero
+const DefaultTimeout = 30 * time.Second
+
+type Server struct {
+	// Client used for every lookup, give it a Cache to share it between the callers
+	Client *omdb.Client
+	// Timeout of the upstream lookups, they are not tied to the request
+	// that started them as other requests may be waiting for the result
+	Timeout time.Duration
+
+	mux       *http.ServeMux
+	group     flightGroup
+	upstream  int64
+	coalesced int64
+}
+
+func New(client *omdb.Client) *Server {
+	s := &Server{Client: client, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/movies/", s.getMovie)
+	s.mux.HandleFunc("/movies", s.findMovie)
+	s.mux.HandleFunc("/search", s.search)
+	s.mux.HandleFunc("/stats", s.stats)
+	s.mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "only GET is supported")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// Error body of every failed request
+type errorBody struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type searchBody struct {
+	Results      []omdb.SearchResult `json:"results"`
+	TotalResults int                 `json:"totalResults"`
+	Page         int                 `json:"page"`
+}
+
+type statsBody struct {
+	Upstream  int64            `json:"upstream"`
+	Coalesced int64            `json:"coalesced"`
+	Cache     *omdb.CacheStats `json:"cache,omitempty"`
+	// Usage of the API keys when the client has a key pool
+	Keys []omdb.KeyUsage `json:"keys,omitempty"`
+}
+
+func (s *Server) getMovie(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/movies/")
+	if !omdb.IsImdbID(id) {
+		writeError(w, http.StatusBadRequest, "invalid_id", "not an IMDb id: "+id)
+		return
+	}
+	s.lookup(w, r, "i="+strings.ToLower(id), func(ctx context.Context) (interface{}, error) {
+		return s.Client.SearchById(ctx, id)
+	})
+}
+
+func (s *Server) findMovie(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	tq := omdb.TitleQuery{Title: strings.TrimSpace(q.Get("title")), Year: q.Get("year"), Type: q.Get("type")}
+	if tq.Title == "" {
+		writeError(w, http.StatusBadRequest, "missing_title", "the title parameter is required")
+		return
+	}
+	key := "t=" + strings.ToLower(tq.Title) + "&y=" + tq.Year + "&type=" + tq.Type
+	s.lookup(w, r, key, func(ctx context.Context) (interface{}, error) {
+		return s.Client.SearchByTitle(ctx, tq)
+	})
+}
+
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	sq := omdb.SearchQuery{Query: strings.TrimSpace(q.Get("q")), Type: q.Get("type"), Year: q.Get("year"), Page: 1}
+	if sq.Query == "" {
+		writeError(w, http.StatusBadRequest, "missing_query", "the q parameter is required")
+		return
+	}
+	if page := q.Get("page"); page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "invalid_page", "invalid page: "+page)
+			return
+		}
+		sq.Page = n
+	}
+	key := "s=" + strings.ToLower(sq.Query) + "&y=" + sq.Year + "&type=" + sq.Type + "&page=" + strconv.Itoa(sq.Page)
+	s.lookup(w, r, key, func(ctx context.Context) (interface{}, error) {
+		page, err := s.Client.Search(ctx, sq)
+		if err != nil {
+			return nil, err
+		}
+		return searchBody{Results: page.Results, TotalResults: page.TotalResults, Page: page.Page}, nil
+	})
+}
+
+func (s *Server) stats(w http.ResponseWriter, r *http.Request) {
+	body := statsBody{Upstream: atomic.LoadInt64(&s.upstream), Coalesced: atomic.LoadInt64(&s.coalesced)}
+	if s.Client.Cache != nil {
+		stats := s.Client.Cache.Stats()
+		body.Cache = &stats
+	}
+	if s.Client.Keys != nil {
+		body.Keys = s.Client.Keys.Usage()
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// Run the lookup once for all the identical requests in flight and write the result
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request, key string, fn func(ctx context.Context) (interface{}, error)) {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	result := make(chan struct{})
+	var value interface{}
+	var err error
+	go func() {
+		defer close(result)
+		var shared bool
+		value, err, shared = s.group.Do(key, func() (interface{}, error) {
+			atomic.AddInt64(&s.upstream, 1)
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			return fn(ctx)
+		})
+		if shared {
+			atomic.AddInt64(&s.coalesced, 1)
+		}
+	}()
+	select {
+	case <-r.Context().Done():
+		return
+	case <-result:
+	}
+	if err != nil {
+		status, code := classify(err)
+		writeError(w, status, code, message(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, value)
+}
+
+// HTTP status and error code of a failed lookup. Problems with the API key
+// or the quota are the gateway's, they are reported as unavailable.
+func classify(err error) (int, string) {
+	var apiErr *omdb.APIError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, omdb.ErrNotFound):
+		return http.StatusNotFound, "not_found"
+	case errors.Is(err, omdb.ErrTooManyResults):
+		return http.StatusUnprocessableEntity, "too_many_results"
+	case errors.Is(err, omdb.ErrRequestLimitReached):
+		return http.StatusServiceUnavailable, "quota_exhausted"
+	case errors.Is(err, omdb.ErrInvalidAPIKey):
+		return http.StatusServiceUnavailable, "upstream_unavailable"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return http.StatusGatewayTimeout, "upstream_timeout"
+	case errors.As(err, &apiErr), errors.As(err, &netErr):
+		return http.StatusBadGateway, "upstream_error"
+	}
+	return http.StatusInternalServerError, "internal_error"
+}
+
+// Message safe to show to the callers
+func message(err error) string {
+	var apiErr *omdb.APIError
+	if errors.As(err, &apiErr) {
+		if errors.Is(err, omdb.ErrInvalidAPIKey) {
+			return "the upstream API rejected the gateway credentials"
+		}
+		return apiErr.Message
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "the upstream API did not answer in time"
+	}
+	if errors.Is(err, errCallPanicked) {
+		return "the lookup failed unexpectedly"
+	}
+	return "the upstream API could not be reached"
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, errorBody{Error: errorDetail{Code: code, Message: message}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
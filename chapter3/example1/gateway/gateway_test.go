@@ -0,0 +1,148 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb/omdbtest"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newGateway(t *testing.T) (*omdbtest.Server, *Server) {
+	srv := omdbtest.MustNewServer(omdbtest.FixtureDir())
+	client := omdb.NewClient(srv.URL, srv.Client(), omdbtest.APIKey, "")
+	client.Cache = omdb.NewLRUCache(100)
+	return srv, New(client)
+}
+
+func get(s *Server, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestRoutes(t *testing.T) {
+	srv, s := newGateway(t)
+	defer srv.Close()
+
+	tests := []struct {
+		path   string
+		status int
+		code   string
+	}{
+		{"/movies/tt3896198", http.StatusOK, ""},
+		{"/movies?title=Inception&year=2010", http.StatusOK, ""},
+		{"/search?q=guardians", http.StatusOK, ""},
+		{"/movies/tt0000001", http.StatusNotFound, "not_found"},
+		{"/movies/abc", http.StatusBadRequest, "invalid_id"},
+		{"/movies", http.StatusBadRequest, "missing_title"},
+		{"/search?q=ab", http.StatusUnprocessableEntity, "too_many_results"},
+	}
+	for _, test := range tests {
+		w := get(s, test.path)
+		if w.Code != test.status {
+			t.Errorf("%s: got status %d, want %d\n%s", test.path, w.Code, test.status, w.Body)
+			continue
+		}
+		if test.code != "" {
+			body := errorBody{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error.Code != test.code {
+				t.Errorf("%s: got body %s", test.path, w.Body)
+			}
+		}
+	}
+
+	srv.SetAPIKeys("another-key")
+	w := get(s, "/movies/tt1375666")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("invalid key: got status %d", w.Code)
+	}
+
+	s.Client.Keys = omdb.NewKeyPool(1000, omdbtest.APIKey, "another-key")
+	if w := get(s, "/movies/tt0468569"); w.Code != http.StatusOK {
+		t.Errorf("key pool: got status %d", w.Code)
+	}
+	stats := statsBody{}
+	if err := json.Unmarshal(get(s, "/stats").Body.Bytes(), &stats); err != nil || len(stats.Keys) != 2 {
+		t.Fatalf("got stats %+v, %v", stats, err)
+	}
+	if k := stats.Keys[0]; k.Key != "te****ey" || !k.Invalid || k.Used != 1 {
+		t.Errorf("got %+v", k)
+	}
+	if k := stats.Keys[1]; k.Key != "an****ey" || k.Used != 1 || k.Remaining != 999 {
+		t.Errorf("got %+v", k)
+	}
+}
+
+func TestCoalescing(t *testing.T) {
+	srv, s := newGateway(t)
+	defer srv.Close()
+	srv.SetLatency(50 * time.Millisecond)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if w := get(s, "/movies/tt3896198"); w.Code != http.StatusOK {
+				t.Errorf("got status %d", w.Code)
+			}
+		}()
+	}
+	wg.Wait()
+	if w := get(s, "/movies/tt3896198"); w.Code != http.StatusOK {
+		t.Errorf("got status %d", w.Code)
+	}
+	if srv.Requests() != 1 {
+		t.Errorf("got %d upstream requests, want 1", srv.Requests())
+	}
+}
+
+func TestLookupPanic(t *testing.T) {
+	srv, s := newGateway(t)
+	defer srv.Close()
+
+	const callers = 3
+	started, release := make(chan struct{}), make(chan struct{})
+	fn := func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-release
+		panic("lookup")
+	}
+	responses := make([]*httptest.ResponseRecorder, callers)
+	wg := sync.WaitGroup{}
+	for i := range responses {
+		responses[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func(w *httptest.ResponseRecorder) {
+			defer wg.Done()
+			s.lookup(w, httptest.NewRequest(http.MethodGet, "/movies/tt3896198", nil), "i=tt3896198", fn)
+		}(responses[i])
+		if i == 0 {
+			<-started
+		}
+	}
+	// Let the other callers join the running call before it panics
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for i, w := range responses {
+		body := errorBody{}
+		json.NewDecoder(w.Body).Decode(&body)
+		if w.Code != http.StatusInternalServerError || body.Error.Message != message(errCallPanicked) {
+			t.Errorf("caller %d: got status %d %+v", i, w.Code, body)
+		}
+	}
+	if s.coalesced != callers-1 {
+		t.Errorf("got %d coalesced callers, want %d", s.coalesced, callers-1)
+	}
+	// The key is free again after the panic
+	if w := get(s, "/movies/tt3896198"); w.Code != http.StatusOK {
+		t.Errorf("after the panic: got status %d", w.Code)
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/Tanmay-Teaches/golang/chapter3/example1
+
+go 1.14
@@ -0,0 +1,317 @@
+// Package index provide a full-text search over movie metadata, without
+// any request to omdbapi.com.
+package index
+
+/*
+The index is an inverted index from the stemmed terms of the title, people,
+genres and plot of the movies to the positions where they appear. The
+documents are ranked with BM25, computed for each field with its own length
+normalization and multiplied by the boost of the field, so a word of the
+title weight more than the same word in the plot.
+
+A query is a list of words, any of them can match, and of phrases between
+double quotes, which must all match as a whole in one field:
+
+	ix := index.New()
+	ix.Add(movie)
+	hits := ix.Search(`nolan "dream sharing"`, 10)
+
+Movies can be added, replaced and removed at any time, the index is safe for
+concurrent use.
+*/
+
+import (
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Indexed fields
+const (
+	FieldTitle    = "title"
+	FieldActors   = "actors"
+	FieldDirector = "director"
+	FieldWriter   = "writer"
+	FieldGenre    = "genre"
+	FieldPlot     = "plot"
+)
+
+// Boost of each field when Index.Boosts is nil
+var DefaultBoosts = map[string]float64{
+	FieldTitle:    3,
+	FieldActors:   2,
+	FieldDirector: 2,
+	FieldWriter:   1.5,
+	FieldGenre:    1.5,
+	FieldPlot:     1,
+}
+
+// BM25 parameters when Index.K1 and Index.B are zero
+const (
+	DefaultK1 = 1.2
+	DefaultB  = 0.75
+)
+
+type Index struct {
+	// Boost of the fields, a field missing from the map is not searched
+	Boosts map[string]float64
+	// BM25 term frequency saturation and length normalization
+	K1, B float64
+
+	mutex sync.RWMutex
+	docs  map[string]*document
+	// term -> field -> document id -> positions
+	postings map[string]map[string]map[string][]int
+	// Total number of terms of each field, for the average length
+	fieldTerms map[string]int
+}
+
+type document struct {
+	movie   *omdb.MovieInfo
+	lengths map[string]int
+	terms   []string
+}
+
+// A movie matching a query. Fields are the fields where the query matched.
+type Hit struct {
+	Movie  *omdb.MovieInfo
+	Score  float64
+	Fields []string
+}
+
+func New() *Index {
+	return &Index{
+		docs:       map[string]*document{},
+		postings:   map[string]map[string]map[string][]int{},
+		fieldTerms: map[string]int{},
+	}
+}
+
+// Return the text of each field of the movie
+func fieldTexts(mi *omdb.MovieInfo) map[string]string {
+	return map[string]string{
+		FieldTitle:    mi.Title,
+		FieldActors:   strings.Join(mi.Actors, ", "),
+		FieldDirector: strings.Join(mi.Director, ", "),
+		FieldWriter:   strings.Join(mi.Writer, ", "),
+		FieldGenre:    strings.Join(mi.Genre, ", "),
+		FieldPlot:     mi.Plot,
+	}
+}
+
+// Add the movie to the index, replacing the movie with the same IMDb id
+func (ix *Index) Add(mi *omdb.MovieInfo) {
+	ix.mutex.Lock()
+	defer ix.mutex.Unlock()
+	id := strings.ToLower(mi.ImdbID)
+	ix.remove(id)
+	doc := &document{movie: mi, lengths: map[string]int{}}
+	seen := map[string]bool{}
+	for field, text := range fieldTexts(mi) {
+		tokens := Tokenize(text)
+		doc.lengths[field] = len(tokens)
+		ix.fieldTerms[field] += len(tokens)
+		for _, t := range tokens {
+			fields, ok := ix.postings[t.Term]
+			if !ok {
+				fields = map[string]map[string][]int{}
+				ix.postings[t.Term] = fields
+			}
+			if fields[field] == nil {
+				fields[field] = map[string][]int{}
+			}
+			fields[field][id] = append(fields[field][id], t.Position)
+			if !seen[t.Term] {
+				seen[t.Term] = true
+				doc.terms = append(doc.terms, t.Term)
+			}
+		}
+	}
+	ix.docs[id] = doc
+}
+
+// Remove the movie with the IMDb id, report if it was in the index
+func (ix *Index) Remove(id string) bool {
+	ix.mutex.Lock()
+	defer ix.mutex.Unlock()
+	return ix.remove(strings.ToLower(id))
+}
+
+func (ix *Index) remove(id string) bool {
+	doc, ok := ix.docs[id]
+	if !ok {
+		return false
+	}
+	for _, term := range doc.terms {
+		fields := ix.postings[term]
+		for field, ids := range fields {
+			delete(ids, id)
+			if len(ids) == 0 {
+				delete(fields, field)
+			}
+		}
+		if len(fields) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	for field, n := range doc.lengths {
+		ix.fieldTerms[field] -= n
+	}
+	delete(ix.docs, id)
+	return true
+}
+
+// Number of movies in the index
+func (ix *Index) Len() int {
+	ix.mutex.RLock()
+	defer ix.mutex.RUnlock()
+	return len(ix.docs)
+}
+
+// Return the n best movies for the query, n <= 0 mean all the matches
+func (ix *Index) Search(query string, n int) []Hit {
+	q := ParseQuery(query)
+	ix.mutex.RLock()
+	defer ix.mutex.RUnlock()
+	boosts := ix.Boosts
+	if boosts == nil {
+		boosts = DefaultBoosts
+	}
+
+	scores := map[string]float64{}
+	matched := map[string]map[string]bool{}
+	match := func(id, field string, score float64) {
+		if score <= 0 {
+			return
+		}
+		scores[id] += score
+		if matched[id] == nil {
+			matched[id] = map[string]bool{}
+		}
+		matched[id][field] = true
+	}
+	for _, term := range q.Terms {
+		for field, ids := range ix.postings[term] {
+			for id, positions := range ids {
+				match(id, field, ix.bm25(term, field, id, len(positions), boosts))
+			}
+		}
+	}
+
+	// Every phrase must match, its score is the score of its terms
+	var candidates map[string]bool
+	for _, phrase := range q.Phrases {
+		found := map[string]bool{}
+		for field := range boosts {
+			for id := range ix.phraseMatches(phrase, field) {
+				if candidates != nil && !candidates[id] {
+					continue
+				}
+				found[id] = true
+				for _, t := range phrase {
+					match(id, field, ix.bm25(t.Term, field, id, len(ix.postings[t.Term][field][id]), boosts))
+				}
+			}
+		}
+		candidates = found
+	}
+
+	hits := []Hit{}
+	for id, score := range scores {
+		if candidates != nil && !candidates[id] {
+			continue
+		}
+		hit := Hit{Movie: ix.docs[id].movie, Score: score}
+		for field := range matched[id] {
+			hit.Fields = append(hit.Fields, field)
+		}
+		sort.Strings(hit.Fields)
+		hits = append(hits, hit)
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Movie.ImdbID < hits[j].Movie.ImdbID
+	})
+	if n > 0 && len(hits) > n {
+		hits = hits[:n]
+	}
+	return hits
+}
+
+// BM25 score of the term for one field of the document, the caller hold the lock
+func (ix *Index) bm25(term, field, id string, freq int, boosts map[string]float64) float64 {
+	boost := boosts[field]
+	if boost == 0 || freq == 0 {
+		return 0
+	}
+	k1, b := ix.K1, ix.B
+	if k1 == 0 {
+		k1 = DefaultK1
+	}
+	if b == 0 {
+		b = DefaultB
+	}
+	df := ix.documentFrequency(term)
+	n := float64(len(ix.docs))
+	idf := math.Log(1 + (n-float64(df)+0.5)/(float64(df)+0.5))
+	avg := float64(ix.fieldTerms[field]) / n
+	length := float64(ix.docs[id].lengths[field])
+	tf := float64(freq)
+	norm := 1 - b
+	if avg > 0 {
+		norm += b * length / avg
+	}
+	return boost * idf * tf * (k1 + 1) / (tf + k1*norm)
+}
+
+// Number of documents with the term in any field, counting each once
+func (ix *Index) documentFrequency(term string) int {
+	ids := map[string]bool{}
+	for _, list := range ix.postings[term] {
+		for id := range list {
+			ids[id] = true
+		}
+	}
+	return len(ids)
+}
+
+// Return the documents where the phrase appear in the field
+func (ix *Index) phraseMatches(phrase []Token, field string) map[string]bool {
+	found := map[string]bool{}
+	if len(phrase) == 0 {
+		return found
+	}
+	first := phrase[0]
+	for id, positions := range ix.postings[first.Term][field] {
+		for _, start := range positions {
+			if ix.phraseAt(phrase, field, id, start-first.Position) {
+				found[id] = true
+				break
+			}
+		}
+	}
+	return found
+}
+
+// Report if every token of the phrase is at its place from the offset
+func (ix *Index) phraseAt(phrase []Token, field, id string, offset int) bool {
+	for _, t := range phrase[1:] {
+		if !containsInt(ix.postings[t.Term][field][id], offset+t.Position) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsInt(list []int, value int) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package index
+
+import (
+	"encoding/json"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb/omdbtest"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"caresses": "caress", "ponies": "poni", "cats": "cat", "feed": "feed",
+		"agreed": "agre", "plastered": "plaster", "motoring": "motor", "sing": "sing",
+		"conflated": "conflat", "hopping": "hop", "falling": "fall", "filing": "file",
+		"happy": "happi", "relational": "relat", "conditional": "condit",
+		"generalization": "gener", "electrical": "electr", "hopefulness": "hope",
+		"adjustment": "adjust", "controlling": "control", "running": "run",
+		"dreams": "dream", "dreaming": "dream", "thief": "thief", "élan": "élan",
+	}
+	for word, want := range tests {
+		if got := Stem(word); got != want {
+			t.Errorf("Stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+// Index the movies of the omdbtest fixtures
+func fixtureIndex(t *testing.T) *Index {
+	files, _ := filepath.Glob(filepath.Join(omdbtest.FixtureDir(), "*.json"))
+	ix := New()
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		movies := []*omdb.MovieInfo{}
+		if err := json.Unmarshal(data, &movies); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		for _, mi := range movies {
+			ix.Add(mi)
+		}
+	}
+	return ix
+}
+
+func ids(hits []Hit) string {
+	list := []string{}
+	for _, hit := range hits {
+		list = append(list, hit.Movie.ImdbID)
+	}
+	return strings.Join(list, ",")
+}
+
+func TestSearch(t *testing.T) {
+	ix := fixtureIndex(t)
+	if ix.Len() != 8 {
+		t.Fatalf("got %d movies", ix.Len())
+	}
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"dreams", "tt1375666"},
+		{`"dream sharing"`, "tt1375666"},
+		{`"sharing dream"`, ""},
+		{`gunn "personal family"`, "tt3896198"},
+		{"Winter", "tt1480055"},
+		{"the of", ""},
+	}
+	for _, test := range tests {
+		if got := ids(ix.Search(test.query, 0)); got != test.want {
+			t.Errorf("%q: got %s, want %s", test.query, got, test.want)
+		}
+	}
+
+	hits := ix.Search("nolan", 2)
+	if got := ids(hits); got != "tt0468569,tt1375666" && got != "tt1375666,tt0468569" {
+		t.Errorf("nolan: got %s", got)
+	}
+	if fields := strings.Join(hits[0].Fields, ","); fields != "director,writer" {
+		t.Errorf("nolan: got fields %s", fields)
+	}
+}
+
+func TestBoostsAndUpdates(t *testing.T) {
+	ix := New()
+	ix.Add(&omdb.MovieInfo{ImdbID: "tt0000001", Title: "Space", Plot: "A crew on a ship."})
+	ix.Add(&omdb.MovieInfo{ImdbID: "tt0000002", Title: "The Ship", Plot: "Lost in space."})
+	if got := ids(ix.Search("space", 0)); got != "tt0000001,tt0000002" {
+		t.Errorf("title boost: got %s", got)
+	}
+	if got := ids(ix.Search("ship", 0)); got != "tt0000002,tt0000001" {
+		t.Errorf("title boost: got %s", got)
+	}
+
+	ix.Add(&omdb.MovieInfo{ImdbID: "tt0000002", Title: "The Boat", Plot: "Lost at sea."})
+	if got := ids(ix.Search("space", 0)); got != "tt0000001" {
+		t.Errorf("after update: got %s", got)
+	}
+	if !ix.Remove("TT0000001") || ix.Remove("tt0000001") || ix.Len() != 1 {
+		t.Errorf("remove: %d movies", ix.Len())
+	}
+	if got := ids(ix.Search("space crew", 0)); got != "" {
+		t.Errorf("after remove: got %s", got)
+	}
+}
@@ -0,0 +1,214 @@
+package index
+
+import (
+	"sort"
+	"strings"
+)
+
+/*
+The Porter stemming algorithm for English, from M.F. Porter, "An algorithm
+for suffix stripping", 1980: "running", "runs" and "run" all become "run",
+"relational" become "relat". Only lower case ASCII words are stemmed.
+*/
+
+type rule struct {
+	suffix      string
+	replacement string
+}
+
+var (
+	step2Rules = sortRules([]rule{
+		{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+		{"izer", "ize"}, {"bli", "ble"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"},
+		{"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"},
+		{"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"}, {"ousness", "ous"},
+		{"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"}, {"logi", "log"},
+	})
+	step3Rules = sortRules([]rule{
+		{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+		{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+	})
+	step4Suffixes = []string{
+		"ement", "ance", "ence", "able", "ible", "ment", "ant", "ent", "ion",
+		"ism", "ate", "iti", "ous", "ive", "ize", "al", "er", "ic", "ou",
+	}
+)
+
+// Longest suffixes first, only the longest matching suffix is considered
+func sortRules(rules []rule) []rule {
+	sort.SliceStable(rules, func(i, j int) bool {
+		return len(rules[i].suffix) > len(rules[j].suffix)
+	})
+	return rules
+}
+
+// Return the stem of a lower case word
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+	word = step1a(word)
+	word = step1b(word)
+	word = step1c(word)
+	word = applyRules(word, step2Rules, 0)
+	word = applyRules(word, step3Rules, 0)
+	word = step4(word)
+	word = step5(word)
+	return word
+}
+
+// Report if the letter at i is a consonant, y is a consonant after a vowel
+func consonant(w string, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !consonant(w, i-1)
+	}
+	return true
+}
+
+// Number of vowel-consonant sequences of the word, m in [C](VC){m}[V]
+func measure(w string) int {
+	m, i := 0, 0
+	for i < len(w) && consonant(w, i) {
+		i++
+	}
+	for i < len(w) {
+		for i < len(w) && !consonant(w, i) {
+			i++
+		}
+		if i == len(w) {
+			break
+		}
+		for i < len(w) && consonant(w, i) {
+			i++
+		}
+		m++
+	}
+	return m
+}
+
+func hasVowel(w string) bool {
+	for i := range w {
+		if !consonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// Report if the word end with a double consonant, like "tt"
+func doubleConsonant(w string) bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && consonant(w, n-1)
+}
+
+// Report if the word end with consonant-vowel-consonant, the last one not w, x or y
+func cvc(w string) bool {
+	n := len(w)
+	if n < 3 || !consonant(w, n-1) || consonant(w, n-2) || !consonant(w, n-3) {
+		return false
+	}
+	c := w[n-1]
+	return c != 'w' && c != 'x' && c != 'y'
+}
+
+func step1a(w string) string {
+	switch {
+	case strings.HasSuffix(w, "sses"):
+		return w[:len(w)-2]
+	case strings.HasSuffix(w, "ies"):
+		return w[:len(w)-2]
+	case strings.HasSuffix(w, "ss"):
+		return w
+	case strings.HasSuffix(w, "s"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+func step1b(w string) string {
+	if strings.HasSuffix(w, "eed") {
+		if measure(w[:len(w)-3]) > 0 {
+			return w[:len(w)-1]
+		}
+		return w
+	}
+	stem := ""
+	switch {
+	case strings.HasSuffix(w, "ed") && hasVowel(w[:len(w)-2]):
+		stem = w[:len(w)-2]
+	case strings.HasSuffix(w, "ing") && hasVowel(w[:len(w)-3]):
+		stem = w[:len(w)-3]
+	default:
+		return w
+	}
+	switch {
+	case strings.HasSuffix(stem, "at"), strings.HasSuffix(stem, "bl"), strings.HasSuffix(stem, "iz"):
+		return stem + "e"
+	case doubleConsonant(stem):
+		if c := stem[len(stem)-1]; c != 'l' && c != 's' && c != 'z' {
+			return stem[:len(stem)-1]
+		}
+	case measure(stem) == 1 && cvc(stem):
+		return stem + "e"
+	}
+	return stem
+}
+
+func step1c(w string) string {
+	if strings.HasSuffix(w, "y") && hasVowel(w[:len(w)-1]) {
+		return w[:len(w)-1] + "i"
+	}
+	return w
+}
+
+// Replace the longest matching suffix if the measure of the stem is above min
+func applyRules(w string, rules []rule, min int) string {
+	for _, r := range rules {
+		if strings.HasSuffix(w, r.suffix) {
+			stem := w[:len(w)-len(r.suffix)]
+			if measure(stem) > min {
+				return stem + r.replacement
+			}
+			return w
+		}
+	}
+	return w
+}
+
+func step4(w string) string {
+	for _, suffix := range step4Suffixes {
+		if !strings.HasSuffix(w, suffix) {
+			continue
+		}
+		stem := w[:len(w)-len(suffix)]
+		if suffix == "ion" && !strings.HasSuffix(stem, "s") && !strings.HasSuffix(stem, "t") {
+			return w
+		}
+		if measure(stem) > 1 {
+			return stem
+		}
+		return w
+	}
+	return w
+}
+
+func step5(w string) string {
+	if strings.HasSuffix(w, "e") {
+		stem := w[:len(w)-1]
+		if m := measure(stem); m > 1 || (m == 1 && !cvc(stem)) {
+			w = stem
+		}
+	}
+	if measure(w) > 1 && doubleConsonant(w) && strings.HasSuffix(w, "l") {
+		w = w[:len(w)-1]
+	}
+	return w
+}
@@ -0,0 +1,64 @@
+package index
+
+import (
+	"strings"
+	"unicode"
+)
+
+// A term of a text and the position of its word, counting the stop words
+type Token struct {
+	Term     string
+	Position int
+}
+
+// Common English words left out of the index
+var stopWords = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`a an and are as at be but by for from has have he her his
+		in into is it its of on or she that the their them they this to was were which while who
+		will with`) {
+		stopWords[word] = true
+	}
+}
+
+// Split the text in lower case words, drop the stop words and stem the others.
+// Apostrophes are removed so "King's" give the same term as "kings".
+func Tokenize(text string) []Token {
+	tokens := []Token{}
+	words := strings.FieldsFunc(strings.ToLower(strings.Replace(text, "'", "", -1)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		if !stopWords[word] {
+			tokens = append(tokens, Token{Term: Stem(word), Position: i})
+		}
+	}
+	return tokens
+}
+
+// A parsed search query
+type Query struct {
+	// Terms of the words outside the quotes, any of them can match
+	Terms []string
+	// Phrases between double quotes, each must match. The positions of the
+	// tokens are relative to the start of the phrase.
+	Phrases [][]Token
+}
+
+// Parse a query like: nolan "dream sharing". An unclosed quote run to the end.
+func ParseQuery(query string) Query {
+	q := Query{}
+	parts := strings.Split(query, `"`)
+	for i, part := range parts {
+		tokens := Tokenize(part)
+		if i%2 == 0 {
+			for _, t := range tokens {
+				q.Terms = append(q.Terms, t.Term)
+			}
+		} else if len(tokens) > 0 {
+			q.Phrases = append(q.Phrases, tokens)
+		}
+	}
+	return q
+}
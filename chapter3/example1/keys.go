@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"strconv"
+)
+
+// Show the daily usage of the API keys, without any request to the API
+func setupKeys(fs *flag.FlagSet) runFunc {
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) != 0 {
+			return usageError("unexpected arguments")
+		}
+		pool := env.client.Keys
+		if pool == nil {
+			pool = omdb.NewKeyPool(0, env.client.APIKey)
+		}
+		usage := pool.Usage()
+		rows := [][]string{{"Key", "Day", "Used", "Limit", "Remaining", "Status"}}
+		for _, u := range usage {
+			limit, remaining := "", ""
+			if u.Limit > 0 {
+				limit, remaining = strconv.Itoa(u.Limit), strconv.Itoa(u.Remaining)
+			}
+			status := "ok"
+			switch {
+			case u.Invalid:
+				status = "invalid"
+			case u.Exhausted || (u.Limit > 0 && u.Remaining == 0):
+				status = "exhausted"
+			}
+			rows = append(rows, []string{u.Key, u.Day, strconv.Itoa(u.Used), limit, remaining, status})
+		}
+		return writeRows(env, usage, rows)
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/index"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/library"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Flag of every library command
+func libraryFlag(fs *flag.FlagSet) *string {
+	return fs.String("library", library.DefaultPath(), "path of the library file, default to $"+library.PathEnv)
+}
+
+// Flags of the personal fields, shared by library add and library set
+type personalFlags struct {
+	tags    *string
+	rating  *int
+	watched *string
+	notes   *string
+	fs      *flag.FlagSet
+}
+
+func newPersonalFlags(fs *flag.FlagSet) *personalFlags {
+	return &personalFlags{
+		tags:    fs.String("tags", "", "comma separated tags, replace the current tags"),
+		rating:  fs.Int("rating", 0, "personal rating from 1 to 10"),
+		watched: fs.String("watched", "", "date watched as YYYY-MM-DD, or today"),
+		notes:   fs.String("notes", "", "free text notes"),
+		fs:      fs,
+	}
+}
+
+// Check the flags and return the function applying the flags that were set
+func (pf *personalFlags) updater() (func(e *library.Entry), error) {
+	set := map[string]bool{}
+	pf.fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["rating"] && (*pf.rating < 1 || *pf.rating > 10) {
+		return nil, usageError("the rating must be between 1 and 10")
+	}
+	watched := time.Time{}
+	if *pf.watched == "today" {
+		watched = time.Now().UTC().Truncate(24 * time.Hour)
+	} else if set["watched"] {
+		var err error
+		if watched, err = library.ParseDate(*pf.watched); err != nil {
+			return nil, usageError(err.Error())
+		}
+	}
+	return func(e *library.Entry) {
+		if set["tags"] {
+			e.Tags = splitList(*pf.tags)
+		}
+		if set["rating"] {
+			e.Rating = *pf.rating
+		}
+		if set["watched"] {
+			e.Watched = watched
+		}
+		if set["notes"] {
+			e.Notes = *pf.notes
+		}
+	}, nil
+}
+
+func splitList(s string) []string {
+	list := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Look up the movies and add them to the library with the personal fields
+func setupLibraryAdd(fs *flag.FlagSet) runFunc {
+	path := libraryFlag(fs)
+	personal := newPersonalFlags(fs)
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) == 0 {
+			return usageError("expected at least one IMDb id")
+		}
+		update, err := personal.updater()
+		if err != nil {
+			return err
+		}
+		store, err := library.Open(*path)
+		if err != nil {
+			return err
+		}
+		entries := []library.Entry{}
+		for _, id := range args {
+			mi, err := env.client.SearchById(ctx, id)
+			if err != nil {
+				return fmt.Errorf("%s: %w", id, err)
+			}
+			e, err := store.Add(mi, update)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, e)
+		}
+		return writeEntries(env, entries)
+	}
+}
+
+// Change the personal fields of movies already in the library, offline
+func setupLibrarySet(fs *flag.FlagSet) runFunc {
+	path := libraryFlag(fs)
+	personal := newPersonalFlags(fs)
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) == 0 {
+			return usageError("expected at least one IMDb id")
+		}
+		update, err := personal.updater()
+		if err != nil {
+			return err
+		}
+		store, err := library.Open(*path)
+		if err != nil {
+			return err
+		}
+		entries := []library.Entry{}
+		for _, id := range args {
+			e, err := store.Update(id, update)
+			if err != nil {
+				return fmt.Errorf("%s: %w", id, err)
+			}
+			entries = append(entries, e)
+		}
+		return writeEntries(env, entries)
+	}
+}
+
+func setupLibraryRemove(fs *flag.FlagSet) runFunc {
+	path := libraryFlag(fs)
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) == 0 {
+			return usageError("expected at least one IMDb id")
+		}
+		store, err := library.Open(*path)
+		if err != nil {
+			return err
+		}
+		for _, id := range args {
+			if err := store.Remove(id); err != nil {
+				return fmt.Errorf("%s: %w", id, err)
+			}
+		}
+		return nil
+	}
+}
+
+func setupLibraryList(fs *flag.FlagSet) runFunc {
+	path := libraryFlag(fs)
+	genre := fs.String("genre", "", "only this genre")
+	from := fs.Int("from", 0, "only the movies released this year or later")
+	to := fs.Int("to", 0, "only the movies released this year or before")
+	minRating := fs.Float64("min-rating", 0, "minimum imdbRating")
+	myRating := fs.Int("my-rating", 0, "minimum personal rating")
+	actor := fs.String("actor", "", "only the movies with this actor, part of the name is enough")
+	tag := fs.String("tag", "", "only the movies with this tag")
+	watched := fs.Bool("watched", false, "only the watched movies")
+	unwatched := fs.Bool("unwatched", false, "only the movies not watched")
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) != 0 {
+			return usageError("unexpected arguments")
+		}
+		if *watched && *unwatched {
+			return usageError("--watched and --unwatched are exclusive")
+		}
+		store, err := library.Open(*path)
+		if err != nil {
+			return err
+		}
+		filter := library.Filter{
+			Genre:       *genre,
+			YearFrom:    *from,
+			YearTo:      *to,
+			MinRating:   *minRating,
+			MinMyRating: *myRating,
+			Actor:       *actor,
+			Tag:         *tag,
+		}
+		if *watched || *unwatched {
+			filter.Watched = watched
+		}
+		return writeEntries(env, store.List(filter))
+	}
+}
+
+// Export to the file or stdout, in CSV for a .csv file or -o csv, JSON otherwise
+func setupLibraryExport(fs *flag.FlagSet) runFunc {
+	path := libraryFlag(fs)
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) > 1 {
+			return usageError("expected at most one file")
+		}
+		store, err := library.Open(*path)
+		if err != nil {
+			return err
+		}
+		w := env.stdout
+		asCSV := env.format == "csv"
+		if len(args) == 1 && args[0] != "-" {
+			file, err := os.Create(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			w = file
+			asCSV = asCSV || strings.EqualFold(filepath.Ext(args[0]), ".csv")
+		}
+		if asCSV {
+			return library.ExportCSV(w, store.List(library.Filter{}))
+		}
+		return library.ExportJSON(w, store.List(library.Filter{}))
+	}
+}
+
+// Import a file written by library export, CSV for a .csv file and JSON otherwise
+func setupLibraryImport(fs *flag.FlagSet) runFunc {
+	path := libraryFlag(fs)
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) != 1 {
+			return usageError("expected one file")
+		}
+		file, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		var entries []library.Entry
+		if strings.EqualFold(filepath.Ext(args[0]), ".csv") {
+			entries, err = library.ImportCSV(file)
+		} else {
+			entries, err = library.ImportJSON(file)
+		}
+		if err != nil {
+			return err
+		}
+		store, err := library.Open(*path)
+		if err != nil {
+			return err
+		}
+		if err := store.Put(entries...); err != nil {
+			return err
+		}
+		fmt.Fprintf(env.stderr, "imported %d movies\n", len(entries))
+		return nil
+	}
+}
+
+func writeEntries(env *environment, entries []library.Entry) error {
+	switch env.format {
+	case "json":
+		return library.ExportJSON(env.stdout, entries)
+	case "csv":
+		return library.ExportCSV(env.stdout, entries)
+	}
+	rows := [][]string{{"imdbID", "Title", "Year", "imdbRating", "MyRating", "Watched", "Tags"}}
+	for _, e := range entries {
+		rating, myRating, watched := "", "", ""
+		if e.Movie.ImdbRating > 0 {
+			rating = strconv.FormatFloat(e.Movie.ImdbRating, 'f', 1, 64)
+		}
+		if e.Rating > 0 {
+			myRating = strconv.Itoa(e.Rating)
+		}
+		if !e.Watched.IsZero() {
+			watched = e.Watched.Format(library.DateLayout)
+		}
+		rows = append(rows, []string{e.ID(), e.Movie.Title, e.Movie.Year, rating, myRating, watched, strings.Join(e.Tags, ", ")})
+	}
+	return writeTable(env.stdout, rows)
+}
+
+// Suggest movies of the library similar to one of them, offline
+func setupRecommend(fs *flag.FlagSet) runFunc {
+	path := libraryFlag(fs)
+	n := fs.Int("n", 10, "number of suggestions")
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) != 1 {
+			return usageError("expected one IMDb id of the library")
+		}
+		store, err := library.Open(*path)
+		if err != nil {
+			return err
+		}
+		recommendations, err := store.Recommend(args[0], *n, library.DefaultWeights)
+		if err != nil {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+		rows := [][]string{{"imdbID", "Title", "Year", "Score", "Matches"}}
+		for _, r := range recommendations {
+			matches := []string{}
+			for _, m := range r.Matches {
+				matches = append(matches, m.Feature+": "+strings.Join(m.Values, ", "))
+			}
+			rows = append(rows, []string{r.Entry.ID(), r.Entry.Movie.Title, r.Entry.Movie.Year,
+				strconv.FormatFloat(r.Score, 'f', 2, 64), strings.Join(matches, "; ")})
+		}
+		return writeRows(env, recommendations, rows)
+	}
+}
+
+// Full-text search of the titles, people, genres and plots of the library, offline
+func setupLibrarySearch(fs *flag.FlagSet) runFunc {
+	path := libraryFlag(fs)
+	n := fs.Int("n", 20, "maximum number of results")
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) == 0 {
+			return usageError("expected a query")
+		}
+		store, err := library.Open(*path)
+		if err != nil {
+			return err
+		}
+		ix := index.New()
+		for _, e := range store.List(library.Filter{}) {
+			mi := e.Movie
+			ix.Add(&mi)
+		}
+		hits := ix.Search(strings.Join(args, " "), *n)
+		rows := [][]string{{"imdbID", "Title", "Year", "Score", "Fields"}}
+		for _, hit := range hits {
+			rows = append(rows, []string{hit.Movie.ImdbID, hit.Movie.Title, hit.Movie.Year,
+				strconv.FormatFloat(hit.Score, 'f', 2, 64), strings.Join(hit.Fields, ", ")})
+		}
+		return writeRows(env, hits, rows)
+	}
+}
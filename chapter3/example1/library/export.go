@@ -0,0 +1,139 @@
+package library
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layout of the dates of the personal columns
+const DateLayout = "2006-01-02"
+
+// Personal columns of the CSV export, after the omdb.FieldNames columns
+var PersonalColumns = []string{"Added", "Watched", "MyRating", "Tags", "Notes"}
+
+// Write the entries as a JSON array
+func ExportJSON(w io.Writer, entries []Entry) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+// Read a JSON array written by ExportJSON
+func ImportJSON(r io.Reader) ([]Entry, error) {
+	entries := []Entry{}
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Write the entries as CSV with a header, the movie columns are formatted
+// as omdbapi.com does and the tags are separated by "|"
+func ExportCSV(w io.Writer, entries []Entry) error {
+	writer := csv.NewWriter(w)
+	writer.Write(append(append([]string{}, omdb.FieldNames...), PersonalColumns...))
+	for _, e := range entries {
+		writer.Write(append(e.Movie.Fields(omdb.FieldNames), personalValues(&e)...))
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func personalValues(e *Entry) []string {
+	rating := ""
+	if e.Rating > 0 {
+		rating = strconv.Itoa(e.Rating)
+	}
+	return []string{formatDate(e.Added), formatDate(e.Watched), rating, strings.Join(e.Tags, "|"), e.Notes}
+}
+
+// Read a CSV with a header naming its columns. The columns are matched by
+// name, ignoring the case, so a CSV missing some of the columns or with
+// extra ones can be imported. The imdbID column is required.
+func ImportCSV(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	personal := map[string]int{}
+	hasID := false
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		header[i] = name
+		hasID = hasID || strings.EqualFold(name, "imdbID")
+		for _, column := range PersonalColumns {
+			if strings.EqualFold(name, column) {
+				personal[column] = i
+			}
+		}
+	}
+	if !hasID {
+		return nil, errors.New("library: CSV without an imdbID column")
+	}
+	value := func(record []string, column string) string {
+		if i, ok := personal[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	entries := []Entry{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+		e := Entry{Movie: *omdb.MovieFromFields(header, record), Notes: value(record, "Notes")}
+		if e.ID() == "" {
+			return nil, fmt.Errorf("library: line %d: empty imdbID", line)
+		}
+		if e.Added, err = ParseDate(value(record, "Added")); err != nil {
+			return nil, fmt.Errorf("library: line %d: %w", line, err)
+		}
+		if e.Watched, err = ParseDate(value(record, "Watched")); err != nil {
+			return nil, fmt.Errorf("library: line %d: %w", line, err)
+		}
+		if rating := value(record, "MyRating"); rating != "" {
+			if e.Rating, err = strconv.Atoi(rating); err != nil || e.Rating < 1 || e.Rating > 10 {
+				return nil, fmt.Errorf("library: line %d: invalid rating %q, expected 1 to 10", line, rating)
+			}
+		}
+		for _, tag := range strings.Split(value(record, "Tags"), "|") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				e.Tags = append(e.Tags, tag)
+			}
+		}
+		entries = append(entries, e)
+	}
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(DateLayout)
+}
+
+// Parse a date of a personal column, an empty value is the zero time
+func ParseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{DateLayout, time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", s)
+}
@@ -0,0 +1,299 @@
+// Package library keep the movies looked up on omdbapi.com in a local store
+// together with personal fields: watched date, rating, tags and notes.
+package library
+
+/*
+The store is a single JSON file rewritten on every change through a
+temporary file, so a crash never leave a half written library.
+*/
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variable overriding the default library path
+const PathEnv = "MOVIE_LIBRARY"
+
+var ErrNotFound = errors.New("library: movie not in the library")
+
+// A movie of the library
+type Entry struct {
+	Movie omdb.MovieInfo `json:"movie"`
+	Added time.Time      `json:"added"`
+	// Zero when not watched
+	Watched time.Time `json:"watched"`
+	// Personal rating from 1 to 10, 0 when not rated
+	Rating int      `json:"rating"`
+	Tags   []string `json:"tags"`
+	Notes  string   `json:"notes"`
+}
+
+func (e *Entry) ID() string {
+	return e.Movie.ImdbID
+}
+
+// Report if the entry has the tag, ignoring the case
+func (e *Entry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Content of the library file
+type file struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+type Store struct {
+	path    string
+	mutex   sync.Mutex
+	entries map[string]*Entry
+}
+
+// Default path of the library: $MOVIE_LIBRARY or movie/library.json
+// under the user configuration directory.
+func DefaultPath() string {
+	if path := os.Getenv(PathEnv); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "movie", "library.json")
+}
+
+// Open the library file, a missing file is an empty library
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, entries: map[string]*Entry{}}
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	f := file{}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	for i := range f.Entries {
+		e := f.Entries[i]
+		s.entries[e.ID()] = &e
+	}
+	return s, nil
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Write the library to disk, the caller hold the mutex
+func (s *Store) save() error {
+	f := file{Version: 1, Entries: s.sorted(Filter{})}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), ".library-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Add the movie or refresh its metadata, the personal fields of a movie
+// already in the library are kept. The updates change the personal fields
+// before the library is saved. Return the stored entry.
+func (s *Store) Add(mi *omdb.MovieInfo, updates ...func(e *Entry)) (Entry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, ok := s.entries[mi.ImdbID]
+	if !ok {
+		e = &Entry{Added: time.Now().UTC()}
+		s.entries[mi.ImdbID] = e
+	}
+	e.Movie = *mi
+	for _, update := range updates {
+		update(e)
+	}
+	return *e, s.save()
+}
+
+// Insert or replace whole entries, used by the imports. Nothing is
+// changed if one of the entries is invalid.
+func (s *Store) Put(entries ...Entry) error {
+	for i := range entries {
+		if entries[i].ID() == "" {
+			return fmt.Errorf("library: entry %d without imdbID", i+1)
+		}
+		if rating := entries[i].Rating; rating < 0 || rating > 10 {
+			return fmt.Errorf("library: %s: invalid rating %d, expected 1 to 10", entries[i].ID(), rating)
+		}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range entries {
+		e := entries[i]
+		if e.Added.IsZero() {
+			e.Added = time.Now().UTC()
+		}
+		s.entries[e.ID()] = &e
+	}
+	return s.save()
+}
+
+// Change the personal fields of a movie of the library
+func (s *Store) Update(id string, update func(e *Entry)) (Entry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, ok := s.entries[id]
+	if !ok {
+		return Entry{}, ErrNotFound
+	}
+	update(e)
+	return *e, s.save()
+}
+
+func (s *Store) Remove(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.entries[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.entries, id)
+	return s.save()
+}
+
+func (s *Store) Get(id string) (Entry, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, ok := s.entries[id]
+	if !ok {
+		return Entry{}, false
+	}
+	return *e, true
+}
+
+func (s *Store) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.entries)
+}
+
+// Return the entries matching the filter sorted by title then year
+func (s *Store) List(f Filter) []Entry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.sorted(f)
+}
+
+func (s *Store) sorted(f Filter) []Entry {
+	entries := []Entry{}
+	for _, e := range s.entries {
+		if f.Match(e) {
+			entries = append(entries, *e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := strings.ToLower(entries[i].Movie.Title), strings.ToLower(entries[j].Movie.Title)
+		if a != b {
+			return a < b
+		}
+		if entries[i].Movie.Year != entries[j].Movie.Year {
+			return entries[i].Movie.Year < entries[j].Movie.Year
+		}
+		return entries[i].ID() < entries[j].ID()
+	})
+	return entries
+}
+
+// Criteria of List, zero values match everything.
+// Genre, Actor and Tag are case insensitive, Actor match part of a name.
+type Filter struct {
+	Genre    string
+	YearFrom int
+	YearTo   int
+	// Minimum imdbRating
+	MinRating float64
+	// Minimum personal rating
+	MinMyRating int
+	Actor       string
+	Tag         string
+	// Only the watched movies when true, only the others when false
+	Watched *bool
+}
+
+func (f Filter) Match(e *Entry) bool {
+	mi := &e.Movie
+	if f.Genre != "" && !containsFold(mi.Genre, f.Genre) {
+		return false
+	}
+	if f.Actor != "" && !anyContainsFold(mi.Actors, f.Actor) {
+		return false
+	}
+	if f.Tag != "" && !e.HasTag(f.Tag) {
+		return false
+	}
+	year := mi.StartYear()
+	if f.YearFrom > 0 && year < f.YearFrom {
+		return false
+	}
+	if f.YearTo > 0 && (year == 0 || year > f.YearTo) {
+		return false
+	}
+	if f.MinRating > 0 && mi.ImdbRating < f.MinRating {
+		return false
+	}
+	if f.MinMyRating > 0 && e.Rating < f.MinMyRating {
+		return false
+	}
+	if f.Watched != nil && *f.Watched == e.Watched.IsZero() {
+		return false
+	}
+	return true
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyContainsFold(list []string, value string) bool {
+	value = strings.ToLower(value)
+	for _, item := range list {
+		if strings.Contains(strings.ToLower(item), value) {
+			return true
+		}
+	}
+	return false
+}
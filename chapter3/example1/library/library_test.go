@@ -0,0 +1,239 @@
+package library
+
+import (
+	"bytes"
+	"context"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb/omdbtest"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Open a library in a temporary directory filled from the fake server
+func openTestStore(t *testing.T, ids ...string) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "library")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := omdbtest.MustNewServer(omdbtest.FixtureDir())
+	defer srv.Close()
+	client := omdb.NewClient(srv.URL, srv.Client(), omdbtest.APIKey, "")
+	store, err := Open(filepath.Join(dir, "sub", "library.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		mi, err := client.SearchById(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Add(mi); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store, func() { os.RemoveAll(dir) }
+}
+
+func ids(entries []Entry) []string {
+	list := []string{}
+	for _, e := range entries {
+		list = append(list, e.ID())
+	}
+	return list
+}
+
+func TestStore(t *testing.T) {
+	store, cleanup := openTestStore(t, "tt3896198", "tt1375666", "tt0944947")
+	defer cleanup()
+
+	watched := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := store.Update("tt1375666", func(e *Entry) {
+		e.Watched, e.Rating, e.Tags = watched, 9, []string{"Nolan"}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Update("tt0000001", func(e *Entry) {}); err != ErrNotFound {
+		t.Errorf("update of a missing movie: %v", err)
+	}
+
+	yes := true
+	tests := []struct {
+		filter Filter
+		want   []string
+	}{
+		{Filter{}, []string{"tt0944947", "tt3896198", "tt1375666"}},
+		{Filter{Genre: "sci-fi"}, []string{"tt1375666"}},
+		{Filter{YearFrom: 2011, YearTo: 2016}, []string{"tt0944947"}},
+		{Filter{Watched: &yes}, []string{"tt1375666"}},
+		{Filter{Tag: "nolan", MinMyRating: 8}, []string{"tt1375666"}},
+		{Filter{Actor: "dicaprio"}, []string{"tt1375666"}},
+	}
+	for _, test := range tests {
+		if got := ids(store.List(test.filter)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%+v: got %v, want %v", test.filter, got, test.want)
+		}
+	}
+
+	// A new lookup refresh the metadata and keep the personal fields
+	e, _ := store.Get("tt1375666")
+	if _, err := store.Add(&e.Movie, func(e *Entry) { e.Notes = "seen twice" }); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := Open(store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, ok := reopened.Get("tt1375666")
+	if !ok || e.Rating != 9 || !e.Watched.Equal(watched) || e.Movie.Title != "Inception" || e.Notes != "seen twice" {
+		t.Errorf("after reopen: %+v", e)
+	}
+	if err := reopened.Remove("tt1375666"); err != nil || reopened.Len() != 2 {
+		t.Errorf("remove: %v, %d entries", err, reopened.Len())
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	store, cleanup := openTestStore(t, "tt3896198", "tt1375666")
+	defer cleanup()
+	store.Update("tt3896198", func(e *Entry) {
+		e.Watched, e.Rating = time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC), 7
+		e.Tags, e.Notes = []string{"marvel", "space"}, "with \"friends\", twice"
+	})
+
+	buf := &bytes.Buffer{}
+	if err := ExportCSV(buf, store.List(Filter{})); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ImportCSV(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := store.List(Filter{})
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i := range want {
+		got, exp := entries[i], want[i]
+		if got.ID() != exp.ID() || got.Movie.Title != exp.Movie.Title || got.Rating != exp.Rating ||
+			!got.Watched.Equal(exp.Watched) || !reflect.DeepEqual(got.Tags, exp.Tags) || got.Notes != exp.Notes ||
+			got.Movie.ImdbRating != exp.Movie.ImdbRating || !reflect.DeepEqual(got.Movie.Genre, exp.Movie.Genre) {
+			t.Errorf("entry %d: got %+v, want %+v", i, got, exp)
+		}
+	}
+
+	for _, rating := range []string{"0", "11", "nine"} {
+		if _, err := ImportCSV(strings.NewReader("imdbID,MyRating\ntt1375666," + rating + "\n")); err == nil {
+			t.Errorf("rating %s: got no error", rating)
+		}
+	}
+}
+
+func TestPutInvalid(t *testing.T) {
+	store, cleanup := openTestStore(t, "tt1375666")
+	defer cleanup()
+
+	tests := []string{
+		`[{"movie": {"imdbID": "tt0468569"}, "rating": 8}, {"movie": {"imdbID": "tt3896198"}, "rating": 42}]`,
+		`[{"movie": {"imdbID": "tt0468569"}, "rating": -3}]`,
+		`[{"movie": {"imdbID": "tt0468569"}}, {"movie": {}}]`,
+	}
+	for _, test := range tests {
+		entries, err := ImportJSON(strings.NewReader(test))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Put(entries...); err == nil {
+			t.Errorf("%s: got no error", test)
+		}
+		if store.Len() != 1 {
+			t.Errorf("%s: got %d entries after a failed put, want 1", test, store.Len())
+		}
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	store, cleanup := openTestStore(t, "tt3896198", "tt2015381", "tt1375666", "tt0468569", "tt0944947")
+	defer cleanup()
+
+	recommendations, err := store.Recommend("tt1375666", 3, DefaultWeights)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recommendations) != 3 || recommendations[0].Entry.ID() != "tt0468569" {
+		t.Fatalf("got %+v", recommendations)
+	}
+	features := map[string]string{}
+	for _, m := range recommendations[0].Matches {
+		features[m.Feature] = strings.Join(m.Values, ", ")
+	}
+	if features[FeatureDirector] != "Christopher Nolan" || features[FeatureDecade] != "2000s" || features[FeatureGenre] != "Action" {
+		t.Errorf("got matches %v", features)
+	}
+	for i := 1; i < len(recommendations); i++ {
+		if recommendations[i].Score > recommendations[i-1].Score {
+			t.Errorf("not sorted: %v", recommendations)
+		}
+	}
+
+	// Same genres, same writer and same decade: the sequel come first
+	recommendations, _ = store.Recommend("tt2015381", 1, DefaultWeights)
+	if len(recommendations) != 1 || recommendations[0].Entry.ID() != "tt3896198" || recommendations[0].Score > 1 {
+		t.Errorf("got %+v", recommendations)
+	}
+	if _, err := store.Recommend("tt0000001", 3, DefaultWeights); err != ErrNotFound {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	store, cleanup := openTestStore(t, "tt3896198", "tt2015381", "tt1375666", "tt0468569", "tt0944947")
+	defer cleanup()
+
+	stats := ComputeStats(store.List(Filter{}))
+	if stats.Movies != 5 || stats.Rated != 5 || stats.AverageRating != 8.52 || stats.RuntimeMinutes != 614 {
+		t.Errorf("got totals %+v", stats)
+	}
+	first := func(dim string) Group {
+		if len(stats.Groups[dim]) == 0 {
+			t.Fatalf("no %s group", dim)
+		}
+		return stats.Groups[dim][0]
+	}
+	tests := []struct {
+		dim  string
+		want Group
+	}{
+		{ByGenre, Group{Name: "Action", Count: 5, AverageRating: 8.52, RuntimeMinutes: 614}},
+		{ByDecade, Group{Name: "2010s", Count: 4, AverageRating: 8.4, RuntimeMinutes: 462}},
+		{ByCountry, Group{Name: "United States", Count: 5, AverageRating: 8.52, RuntimeMinutes: 614}},
+		{ByLanguage, Group{Name: "English", Count: 5, AverageRating: 8.52, RuntimeMinutes: 614}},
+		{ByRated, Group{Name: "PG-13", Count: 4, AverageRating: 8.35, RuntimeMinutes: 557}},
+		{ByDirector, Group{Name: "Christopher Nolan", Count: 2, AverageRating: 8.9, RuntimeMinutes: 300}},
+	}
+	for _, test := range tests {
+		got := first(test.dim)
+		if got.Name != test.want.Name || got.Count != test.want.Count ||
+			got.AverageRating != test.want.AverageRating || got.RuntimeMinutes != test.want.RuntimeMinutes {
+			t.Errorf("%s: got %+v, want %+v", test.dim, got, test.want)
+		}
+	}
+	if n := len(stats.Groups[ByGenre]); n != 6 {
+		t.Errorf("got %d genres", n)
+	}
+	counts := []int{}
+	for _, b := range stats.Ratings {
+		counts = append(counts, b.Count)
+	}
+	if want := []int{0, 0, 0, 0, 0, 0, 0, 1, 2, 2}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("got histogram %v, want %v", counts, want)
+	}
+
+	if empty := ComputeStats(nil); empty.Movies != 0 || empty.AverageRating != 0 || len(empty.Groups[ByGenre]) != 0 {
+		t.Errorf("got %+v", empty)
+	}
+}
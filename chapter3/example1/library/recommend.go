@@ -0,0 +1,148 @@
+package library
+
+import (
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Features compared by Recommend
+const (
+	FeatureGenre    = "genre"
+	FeatureDirector = "director"
+	FeatureWriter   = "writer"
+	FeatureActor    = "actor"
+	FeatureDecade   = "decade"
+	FeatureLanguage = "language"
+)
+
+// Weight of each feature in the score of a recommendation
+type Weights struct {
+	Genre    float64
+	Director float64
+	Writer   float64
+	Actor    float64
+	Decade   float64
+	Language float64
+}
+
+var DefaultWeights = Weights{Genre: 3, Director: 3, Writer: 2, Actor: 2, Decade: 1, Language: 0.5}
+
+func (w Weights) total() float64 {
+	return w.Genre + w.Director + w.Writer + w.Actor + w.Decade + w.Language
+}
+
+// A feature the movies share and its part of the score
+type Match struct {
+	Feature string   `json:"feature"`
+	Values  []string `json:"values"`
+	Score   float64  `json:"score"`
+}
+
+// A movie of the library similar to the seed. Score is from 0 to 1, the sum
+// of the scores of the matches.
+type Recommendation struct {
+	Entry   Entry   `json:"entry"`
+	Score   float64 `json:"score"`
+	Matches []Match `json:"matches"`
+}
+
+// Return at most n movies of the library similar to the movie id, which must
+// be in the library. No request is sent, only the stored metadata is used.
+func (s *Store) Recommend(id string, n int, w Weights) ([]Recommendation, error) {
+	seed, ok := s.Get(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return Recommend(&seed.Movie, s.List(Filter{}), n, w), nil
+}
+
+// Rank the entries by similarity to the seed and return the n best, n <= 0
+// mean all of them. The seed itself and the entries sharing nothing with it
+// are left out. Ties are broken by imdbRating.
+func Recommend(seed *omdb.MovieInfo, entries []Entry, n int, w Weights) []Recommendation {
+	total := w.total()
+	if total <= 0 {
+		return nil
+	}
+	recommendations := []Recommendation{}
+	for _, e := range entries {
+		if strings.EqualFold(e.ID(), seed.ImdbID) {
+			continue
+		}
+		r := Recommendation{Entry: e, Matches: compare(seed, &e.Movie, w, total)}
+		for _, m := range r.Matches {
+			r.Score += m.Score
+		}
+		if r.Score > 0 {
+			recommendations = append(recommendations, r)
+		}
+	}
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		a, b := recommendations[i], recommendations[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Entry.Movie.ImdbRating > b.Entry.Movie.ImdbRating
+	})
+	if n > 0 && len(recommendations) > n {
+		recommendations = recommendations[:n]
+	}
+	return recommendations
+}
+
+// Return the matching features, the scores divided by the total weight
+func compare(seed, mi *omdb.MovieInfo, w Weights, total float64) []Match {
+	matches := []Match{}
+	add := func(feature string, weight float64, a, b []string) {
+		shared := intersect(a, b)
+		if weight <= 0 || len(shared) == 0 {
+			return
+		}
+		// Dice coefficient: 1 when the lists are the same
+		dice := 2 * float64(len(shared)) / float64(len(a)+len(b))
+		matches = append(matches, Match{Feature: feature, Values: shared, Score: weight * dice / total})
+	}
+	add(FeatureGenre, w.Genre, seed.Genre, mi.Genre)
+	add(FeatureDirector, w.Director, seed.Director, mi.Director)
+	add(FeatureWriter, w.Writer, withoutRoles(seed.Writer), withoutRoles(mi.Writer))
+	add(FeatureActor, w.Actor, seed.Actors, mi.Actors)
+	add(FeatureLanguage, w.Language, seed.Language, mi.Language)
+
+	// Same decade score the full weight, the next or previous one half of it
+	a, b := seed.StartYear()/10, mi.StartYear()/10
+	if w.Decade > 0 && a > 0 && b > 0 && a-b <= 1 && b-a <= 1 {
+		m := Match{Feature: FeatureDecade, Values: []string{strconv.Itoa(b*10) + "s"}, Score: w.Decade / total}
+		if a != b {
+			m.Score /= 2
+		}
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+// Values of b also in a, ignoring the case, in the order of b
+func intersect(a, b []string) []string {
+	shared := []string{}
+	for _, value := range b {
+		if containsFold(a, value) && !containsFold(shared, value) {
+			shared = append(shared, value)
+		}
+	}
+	return shared
+}
+
+// OMDb writers carry their role: "Jonathan Nolan (screenplay)" become "Jonathan Nolan"
+func withoutRoles(names []string) []string {
+	list := make([]string, 0, len(names))
+	for _, name := range names {
+		if i := strings.Index(name, " ("); i > 0 {
+			name = name[:i]
+		}
+		if !containsFold(list, name) {
+			list = append(list, name)
+		}
+	}
+	return list
+}
@@ -0,0 +1,129 @@
+package library
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Dimensions of Stats
+const (
+	ByGenre    = "genre"
+	ByDecade   = "decade"
+	ByCountry  = "country"
+	ByLanguage = "language"
+	ByRated    = "rated"
+	ByDirector = "director"
+)
+
+var Dimensions = []string{ByGenre, ByDecade, ByCountry, ByLanguage, ByRated, ByDirector}
+
+// Movies sharing a value of a dimension, a movie of several genres count in each
+type Group struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	// Average imdbRating of the movies that have one, 0 when none has
+	AverageRating  float64 `json:"averageRating"`
+	RuntimeMinutes int     `json:"runtimeMinutes"`
+
+	rated int
+	sum   float64
+}
+
+// Number of movies with an imdbRating from From included to To excluded,
+// the last bucket include 10
+type Bucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
+
+type Stats struct {
+	Movies         int     `json:"movies"`
+	Rated          int     `json:"rated"`
+	AverageRating  float64 `json:"averageRating"`
+	RuntimeMinutes int     `json:"runtimeMinutes"`
+	// Groups of each dimension, the largest first
+	Groups map[string][]Group `json:"groups"`
+	// imdbRating by steps of 1
+	Ratings []Bucket `json:"ratings"`
+}
+
+// Aggregate the entries by genre, decade, country, language, rated certificate and director
+func ComputeStats(entries []Entry) *Stats {
+	s := &Stats{Movies: len(entries), Groups: map[string][]Group{}}
+	for i := 0; i < 10; i++ {
+		s.Ratings = append(s.Ratings, Bucket{From: float64(i), To: float64(i + 1)})
+	}
+	groups := map[string]map[string]*Group{}
+	for _, dim := range Dimensions {
+		groups[dim] = map[string]*Group{}
+	}
+	sum := 0.0
+	for i := range entries {
+		mi := &entries[i].Movie
+		runtime := int(mi.Runtime / time.Minute)
+		s.RuntimeMinutes += runtime
+		if mi.ImdbRating > 0 {
+			s.Rated++
+			sum += mi.ImdbRating
+			bucket := int(mi.ImdbRating)
+			if bucket > 9 {
+				bucket = 9
+			}
+			s.Ratings[bucket].Count++
+		}
+		values := map[string][]string{
+			ByGenre:    mi.Genre,
+			ByCountry:  mi.Country,
+			ByLanguage: mi.Language,
+			ByDirector: mi.Director,
+		}
+		if year := mi.StartYear(); year > 0 {
+			values[ByDecade] = []string{strconv.Itoa(year/10*10) + "s"}
+		}
+		if mi.Rated != "" {
+			values[ByRated] = []string{mi.Rated}
+		}
+		for dim, names := range values {
+			for _, name := range names {
+				g, ok := groups[dim][name]
+				if !ok {
+					g = &Group{Name: name}
+					groups[dim][name] = g
+				}
+				g.Count++
+				g.RuntimeMinutes += runtime
+				if mi.ImdbRating > 0 {
+					g.rated++
+					g.sum += mi.ImdbRating
+				}
+			}
+		}
+	}
+	if s.Rated > 0 {
+		s.AverageRating = round(sum / float64(s.Rated))
+	}
+	for _, dim := range Dimensions {
+		list := []Group{}
+		for _, g := range groups[dim] {
+			if g.rated > 0 {
+				g.AverageRating = round(g.sum / float64(g.rated))
+			}
+			list = append(list, *g)
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Count != list[j].Count {
+				return list[i].Count > list[j].Count
+			}
+			return list[i].Name < list[j].Name
+		})
+		s.Groups[dim] = list
+	}
+	return s
+}
+
+// Round to 2 decimals, the ratings have one
+func round(f float64) float64 {
+	return float64(int(f*100+0.5)) / 100
+}
@@ -2,91 +2,299 @@ package main
 
 /*
 Example of only using many build-in packages in Go to reach out to a rest API to retrieve movie detail.
+
+Command line tool for omdbapi.com, build it with: go build -o movie
+
+	movie get <imdb-id> [--poster DIR] [--thumbs 100,300]
+	movie find <title> [--year YEAR] [--type TYPE] [--exact] [--poster DIR]
+	movie search <query> [--type TYPE] [--year YEAR] [--page N] [--all-pages]
+	movie season <imdb-id> <n>
+	movie batch [file] [--out FILE] [--workers N] [--ordered]
+	movie enrich [file.csv] [--out FILE] [--column NAME] [--fields imdbID,Genre,...] [--workers N] [--report FILE]
+	movie serve [--addr :8080] [--cache DIR]
+	movie library add <imdb-id>... [--tags a,b] [--rating N] [--watched DATE|today] [--notes TEXT]
+	movie library set <imdb-id>... [--tags a,b] [--rating N] [--watched DATE|today] [--notes TEXT]
+	movie library remove <imdb-id>...
+	movie library list [--genre G] [--from YEAR] [--to YEAR] [--min-rating R] [--actor NAME] [--tag T] [--watched|--unwatched]
+	movie library search <words or "phrase">... [-n 20]
+	movie library export [file]
+	movie library import <file>
+	movie recommend <imdb-id> [-n 10]
+	movie keys [--key-usage FILE]
+	movie stats [--by genre,decade,country,language,rated,director] [--top 10] [--genre G] [--from YEAR] [--to YEAR] [--tag T]
+
+Every command accept -o table|json|csv, batch always write NDJSON and enrich CSV. The API key
+is read from the OMDB_API_KEY environment variable or the -apikey flag. Several keys separated
+by commas are used in turn: the next key is taken when one reach its -key-limit requests of the
+day or the API refuse it, the daily usage is kept in the -key-usage file and shown by keys. With -dataset FILE, get,
+find and search fall back to an offline dump of movies when omdbapi.com fail, and fill the
+fields it miss.
+The library commands keep the movies in a local file, set with --library or the MOVIE_LIBRARY
+environment variable; only library add reach the API. recommend suggest movies of the library
+sharing genres, people, decade and language with one of them. stats count the movies of the
+library by genre, decade, country, language, rated certificate and director, with their average
+imdbRating and total runtime, as tables and bar charts, or as JSON for dashboards.
+
+Exit codes:
+	0 success
+	1 any other error
+	2 bad usage
+	3 not found, or a title matching several movies
+	4 refused by the API (invalid key, request limit, too many results)
+	5 transport error (network, timeout, HTTP 5xx)
 */
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
-	"io/ioutil"
-	"net/http"
-	"net/url"
+	"flag"
+	"fmt"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/library"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/provider"
+	"io"
+	"net"
+	"os"
+	"os/signal"
 	"strings"
+	"time"
 )
 
-//omdbapi.com API key
-const APIKEY = "193ef3a"
-
-//The structure of the return JSON from omdbapi.com
-//To keep this example short, some of the value are not map into the structure
-type MovieInfo struct {
-	Title      string `json:"Title"`
-	Year       string `json:"Year"`
-	Rated      string `json:"Rated"`
-	Released   string `json:"Released"`
-	Runtime    string `json:"Runtime"`
-	Genre      string `json:"Genre"`
-	Writer     string `json:"Writer"`
-	Actors     string `json:"Actors"`
-	Plot       string `json:"Plot"`
-	Language   string `json:"Language"`
-	Country    string `json:"Country"`
-	Awards     string `json:"Awards"`
-	Poster     string `json:"Poster"`
-	ImdbRating string `json:"imdbRating"`
-	ImdbID     string `json:"imdbID"`
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitNotFound
+	exitRefused
+	exitTransport
+)
+
+// Environment variable read for the -key-usage file
+const keyUsageEnv = "OMDB_KEY_USAGE"
+
+type command struct {
+	name  string
+	usage string
+	// Register the flags of the command and return the function running it
+	setup func(fs *flag.FlagSet) runFunc
+}
+
+type runFunc func(ctx context.Context, env *environment, args []string) error
+
+// What the commands share: the client built from the common flags and where to write
+type environment struct {
+	client *omdb.Client
+	// The client alone, or the client then the dataset with -dataset
+	provider provider.MovieProvider
+	format   string
+	stdout   io.Writer
+	stderr   io.Writer
+}
+
+var commands = []*command{
+	{"get", "get <imdb-id> [--poster DIR] [--thumbs 100,300]", setupGet},
+	{"find", "find <title> [--year YEAR] [--type TYPE] [--exact] [--poster DIR]", setupFind},
+	{"search", "search <query> [--type TYPE] [--year YEAR] [--page N] [--all-pages]", setupSearch},
+	{"season", "season <imdb-id> <n>", setupSeason},
+	{"batch", "batch [file] [--out FILE] [--workers N] [--ordered]", setupBatch},
+	{"enrich", "enrich [file.csv] [--out FILE] [--column NAME] [--fields imdbID,Genre,...] [--workers N] [--report FILE]", setupEnrich},
+	{"serve", "serve [--addr :8080] [--cache DIR]", setupServe},
+	{"library add", "library add <imdb-id>... [--tags a,b] [--rating N] [--watched DATE|today] [--notes TEXT]", setupLibraryAdd},
+	{"library set", "library set <imdb-id>... [--tags a,b] [--rating N] [--watched DATE|today] [--notes TEXT]", setupLibrarySet},
+	{"library remove", "library remove <imdb-id>...", setupLibraryRemove},
+	{"library list", "library list [--genre G] [--from YEAR] [--to YEAR] [--min-rating R] [--my-rating N] [--actor NAME] [--tag T] [--watched|--unwatched]", setupLibraryList},
+	{"library search", `library search <words or "phrase">... [-n 20]`, setupLibrarySearch},
+	{"library export", "library export [file]", setupLibraryExport},
+	{"library import", "library import <file>", setupLibraryImport},
+	{"recommend", "recommend <imdb-id> [-n 10]", setupRecommend},
+	{"keys", "keys [--key-usage FILE]", setupKeys},
+	{"stats", "stats [--by genre,decade,country,language,rated,director] [--top 10] [--genre G] [--from YEAR] [--to YEAR] [--tag T]", setupStats},
+}
+
+// Error for a wrong command line
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
 }
 
 func main() {
-	body, _ := SearchById("tt3896198")
-	println(body.Title)
-	body, _ = SearchByName("Game of")
-	println(body.Title)
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		cancel()
+	}()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
 }
 
-func SearchByName(name string) (*MovieInfo, error) {
-	parms := url.Values{}
-	parms.Set("apikey", APIKEY)
-	parms.Set("t", name)
-	siteURL := "http://www.omdbapi.com/?" + parms.Encode()
-	body, err := sendGetRequest(siteURL)
-	if err != nil {
-		return nil, errors.New(err.Error() + "\nBody:" + body)
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return exitUsage
 	}
-	mi := &MovieInfo{}
-	return mi, json.Unmarshal([]byte(body), mi)
+	for _, cmd := range commands {
+		// The name of a command can be two words, like "library add"
+		words := len(strings.Fields(cmd.name))
+		if len(args) < words || cmd.name != strings.Join(args[:words], " ") {
+			continue
+		}
+		runCmd, env, rest, err := parseCommandLine(cmd, args[words:], stdout, stderr)
+		if err == nil {
+			err = runCmd(ctx, env, rest)
+			// The pool save the counts of ordinary requests only now and then
+			if keys := env.client.Keys; keys != nil {
+				if saveErr := keys.Save(); saveErr != nil {
+					fmt.Fprintln(stderr, "movie: saving the key usage:", saveErr)
+				}
+			}
+		}
+		if err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return exitOK
+			}
+			fmt.Fprintln(stderr, "movie "+cmd.name+":", err)
+			if _, ok := err.(usageError); ok {
+				fmt.Fprintln(stderr, "usage: movie", cmd.usage)
+			}
+		}
+		return exitCode(err)
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stdout)
+		return exitOK
+	}
+	fmt.Fprintln(stderr, "movie: unknown command", args[0])
+	printUsage(stderr)
+	return exitUsage
 }
 
-func SearchById(id string) (*MovieInfo, error) {
-	parms := url.Values{}
-	parms.Set("apikey", APIKEY)
-	parms.Set("i", id)
-	siteURL := "http://www.omdbapi.com/?" + parms.Encode()
-	body, err := sendGetRequest(siteURL)
-	if err != nil {
-		return nil, errors.New(err.Error() + "\nBody:" + body)
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage:")
+	for _, cmd := range commands {
+		fmt.Fprintln(w, "  movie", cmd.usage)
 	}
-	mi := &MovieInfo{}
-	return mi, json.Unmarshal([]byte(body), mi)
+	fmt.Fprintln(w, "run movie <command> -h for the flags of a command")
 }
 
-func sendGetRequest(url string) (string, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", err
+// Map an error to the exit code of the process
+func exitCode(err error) int {
+	var netErr net.Error
+	var apiErr *omdb.APIError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, new(usageError)):
+		return exitUsage
+	case errors.Is(err, omdb.ErrNotFound), errors.Is(err, library.ErrNotFound), errors.As(err, new(*omdb.AmbiguousError)):
+		return exitNotFound
+	case errors.Is(err, omdb.ErrInvalidAPIKey), errors.Is(err, omdb.ErrRequestLimitReached), errors.Is(err, omdb.ErrTooManyResults):
+		return exitRefused
+	case errors.As(err, &apiErr) && apiErr.StatusCode >= 500:
+		return exitTransport
+	case errors.As(err, &netErr), errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return exitTransport
+	}
+	return exitError
+}
+
+// Flags shared by every command
+type commonFlags struct {
+	format  *string
+	apiKey  *string
+	baseURL *string
+	timeout *time.Duration
+	retries *int
+	cache   *string
+	dataset *string
+	wire    *string
+	limit   *int
+	usage   *string
+}
+
+func newFlagSet(cmd *command, stderr io.Writer) (*flag.FlagSet, *commonFlags) {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: movie", cmd.usage)
+		fs.PrintDefaults()
 	}
-	statusCode := resp.Status
-	if i := strings.IndexByte(resp.Status, ' '); i != -1 {
-		statusCode = resp.Status[:i]
+	return fs, &commonFlags{
+		format:  fs.String("o", "table", "output format: table, json or csv"),
+		apiKey:  fs.String("apikey", "", "omdbapi.com API key, or comma separated keys used in turn, default to $"+omdb.APIKeyEnv),
+		baseURL: fs.String("base-url", omdb.DefaultBaseURL, "base URL of the API"),
+		timeout: fs.Duration("timeout", 30*time.Second, "timeout of each HTTP request"),
+		retries: fs.Int("retries", 3, "number of attempts for failed requests"),
+		cache:   fs.String("cache", "", "directory of an on-disk cache of the answers"),
+		wire:    fs.String("api-format", omdb.FormatJSON, "format requested from the API: json or xml"),
+		dataset: fs.String("dataset", "", "JSON or CSV dump of movies used when omdbapi.com fail or miss fields"),
+		limit:   fs.Int("key-limit", 1000, "daily number of requests of each API key, 0 for no limit"),
+		usage:   fs.String("key-usage", os.Getenv(keyUsageEnv), "file keeping the daily usage of the API keys, default to $"+keyUsageEnv),
 	}
+}
 
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+// Parse the common and the command flags, build the client from the common flags
+func parseCommandLine(cmd *command, args []string, stdout, stderr io.Writer) (runFunc, *environment, []string, error) {
+	fs, common := newFlagSet(cmd, stderr)
+	runCmd := cmd.setup(fs)
+	rest, err := parseInterspersed(fs, args)
 	if err != nil {
-		return "", err
+		return nil, nil, nil, err
+	}
+	switch *common.format {
+	case "table", "json", "csv":
+	default:
+		return nil, nil, nil, usageError("unknown output format " + *common.format)
+	}
+	if *common.wire != omdb.FormatJSON && *common.wire != omdb.FormatXML {
+		return nil, nil, nil, usageError("unknown API format " + *common.wire)
 	}
+	client := omdb.NewClient(*common.baseURL, nil, *common.apiKey, "")
+	// Several keys, or a usage file to keep the count of one key, make a pool
+	if keys := splitList(client.APIKey); len(keys) > 1 || *common.usage != "" {
+		pool := omdb.NewKeyPool(*common.limit, keys...)
+		if *common.usage != "" {
+			if pool, err = omdb.LoadKeyPool(*common.usage, *common.limit, keys...); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+		client.Keys = pool
+	}
+	client.Format = *common.wire
+	client.HTTPClient.Timeout = *common.timeout
+	client.Retry = omdb.RetryPolicy{MaxAttempts: *common.retries}
+	if *common.cache != "" {
+		disk, err := omdb.NewDiskCache(*common.cache)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		client.Cache = omdb.NewTieredCache(omdb.NewLRUCache(1000), disk)
+	}
+	env := &environment{client: client, provider: provider.NewOMDb(client), format: *common.format, stdout: stdout, stderr: stderr}
+	if *common.dataset != "" {
+		dataset, err := provider.LoadDataset(*common.dataset)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		env.provider = provider.NewComposite(env.provider, dataset)
+	}
+	return runCmd, env, rest, nil
+}
 
-	if statusCode != "200" {
-		return string(body), errors.New(resp.Status)
+// Parse the flags wherever they are on the command line, the flag package
+// stop at the first argument so "find Inception --year 2010" need a loop.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	rest := []string{}
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return rest, nil
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
 	}
-	return string(body), nil
 }
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb/omdbtest"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runCommand(args ...string) (int, string, string) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run(context.Background(), args, stdout, stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// Run the command against the fake server
+func runAgainst(srv *omdbtest.Server, args ...string) (int, string, string) {
+	return runCommand(append(args, "-base-url", srv.URL, "-apikey", omdbtest.APIKey)...)
+}
+
+func TestGetFormats(t *testing.T) {
+	srv := omdbtest.MustNewServer(omdbtest.FixtureDir())
+	defer srv.Close()
+
+	code, out, _ := runAgainst(srv, "get", "tt3896198")
+	if code != exitOK || !strings.Contains(out, "Title:") || !strings.Contains(out, "Action, Adventure, Comedy") {
+		t.Errorf("table: code %d output:\n%s", code, out)
+	}
+	code, out, _ = runAgainst(srv, "get", "-o", "json", "tt3896198")
+	if code != exitOK || !strings.Contains(out, `"imdbRating": "7.6"`) {
+		t.Errorf("json: code %d output:\n%s", code, out)
+	}
+	code, out, _ = runAgainst(srv, "get", "-api-format", "xml", "tt3896198")
+	if code != exitOK || !strings.Contains(out, "Action, Adventure, Comedy") {
+		t.Errorf("xml: code %d output:\n%s", code, out)
+	}
+	code, out, _ = runAgainst(srv, "search", "Game of", "-o", "csv")
+	if code != exitOK || out != "imdbID,Title,Year,Type\ntt0944947,Game of Thrones,2011–2019,series\n" {
+		t.Errorf("csv: code %d output:\n%s", code, out)
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	srv := omdbtest.MustNewServer(omdbtest.FixtureDir())
+	defer srv.Close()
+
+	tests := []struct {
+		args []string
+		fail int
+		want int
+	}{
+		{[]string{"find", "No Such Movie"}, 0, exitNotFound},
+		{[]string{"find", "Guardians"}, 0, exitNotFound},
+		{[]string{"find", "Game of"}, 0, exitOK},
+		{[]string{"find", "Game of", "--exact"}, 0, exitNotFound},
+		{[]string{"get", "tt3896198", "-retries", "1"}, http.StatusInternalServerError, exitTransport},
+		{[]string{"season", "tt0944947", "x"}, 0, exitUsage},
+		{[]string{"get", "-o", "xml", "tt3896198"}, 0, exitUsage},
+		{[]string{"unknown"}, 0, exitUsage},
+	}
+	for _, test := range tests {
+		if test.fail != 0 {
+			srv.FailNext(1, test.fail, "")
+		}
+		if code, _, stderr := runAgainst(srv, test.args...); code != test.want {
+			t.Errorf("%v: got exit code %d, want %d\n%s", test.args, code, test.want, stderr)
+		}
+	}
+	srv.SetAPIKeys("another-key")
+	if code, _, _ := runAgainst(srv, "get", "tt3896198"); code != exitRefused {
+		t.Errorf("invalid key: got exit code %d, want %d", code, exitRefused)
+	}
+
+	dir, err := ioutil.TempDir("", "movie-keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	keys := []string{"-apikey", omdbtest.APIKey + ",another-key", "-key-usage", filepath.Join(dir, "usage.json")}
+	if code, _, stderr := runCommand(append([]string{"get", "tt3896198", "-base-url", srv.URL}, keys...)...); code != exitOK {
+		t.Errorf("key pool: got exit code %d\n%s", code, stderr)
+	}
+	code, out, _ := runCommand(append([]string{"keys", "-o", "csv", "-key-limit", "10"}, keys...)...)
+	if code != exitOK || !strings.HasPrefix(out, "Key,Day,Used,Limit,Remaining,Status\nte****ey,") ||
+		!strings.Contains(out, ",1,10,9,ok\nan****ey,") {
+		t.Errorf("keys: code %d output:\n%s", code, out)
+	}
+}
+
+func TestBatchResume(t *testing.T) {
+	srv := omdbtest.MustNewServer(omdbtest.FixtureDir())
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "movie-batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	in := filepath.Join(dir, "ids.txt")
+	out := filepath.Join(dir, "out.ndjson")
+	ioutil.WriteFile(in, []byte("tt3896198\ntt1375666\nNo Such Movie\n"), 0644)
+	ioutil.WriteFile(out+".checkpoint", []byte("0\n"), 0644)
+
+	srv.FailNext(1, http.StatusInternalServerError, "")
+	code, _, stderr := runAgainst(srv, "batch", in, "-out", out, "-retries", "1", "-workers", "1", "-ordered")
+	if code != exitOK {
+		t.Fatalf("got exit code %d\n%s", code, stderr)
+	}
+	data, _ := ioutil.ReadFile(out)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], `"code":3`) || !strings.Contains(stderr, "line 2: tt1375666:") {
+		t.Errorf("got output:\n%s\nstderr:\n%s", data, stderr)
+	}
+	checkpoint, _ := ioutil.ReadFile(out + ".checkpoint")
+	if string(checkpoint) != "0\n2\n" {
+		t.Errorf("got checkpoint %q", checkpoint)
+	}
+
+	code, _, _ = runAgainst(srv, "batch", in, "-out", out)
+	data, _ = ioutil.ReadFile(out)
+	if code != exitOK || !strings.Contains(string(data), `"imdbID":"tt1375666"`) {
+		t.Errorf("resume: code %d output:\n%s", code, data)
+	}
+	// One line for each index not in the first checkpoint
+	count := map[int]int{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var result batchLine
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		count[result.Index]++
+	}
+	if len(count) != 2 || count[1] != 1 || count[2] != 1 {
+		t.Errorf("resume: got lines per index %v\n%s", count, data)
+	}
+
+	// A crash after the line of index 1 was written but before it was
+	// recorded, in the middle of the line of index 2
+	crashed := filepath.Join(dir, "crashed.ndjson")
+	ioutil.WriteFile(crashed, []byte(`{"index":1,"query":"tt1375666","movie":{"imdbID":"tt1375666"}}`+"\n"+`{"index":2,"qu`), 0644)
+	ioutil.WriteFile(crashed+".checkpoint", []byte("0\n"), 0644)
+	code, _, stderr = runAgainst(srv, "batch", in, "-out", crashed)
+	data, _ = ioutil.ReadFile(crashed)
+	lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+	if code != exitOK || len(lines) != 3 || !strings.HasPrefix(lines[2], `{"index":2,"query":"No Such Movie"`) {
+		t.Errorf("crash: code %d output:\n%s\n%s", code, data, stderr)
+	}
+}
+
+func TestLibraryCommands(t *testing.T) {
+	srv := omdbtest.MustNewServer(omdbtest.FixtureDir())
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "movie-library")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	lib := filepath.Join(dir, "library.json")
+
+	code, _, stderr := runAgainst(srv, "library", "add", "tt1375666", "tt3896198", "--library", lib, "--tags", "watchlist")
+	if code != exitOK {
+		t.Fatalf("add: got exit code %d\n%s", code, stderr)
+	}
+	code, _, stderr = runCommand("library", "set", "tt1375666", "--library", lib, "--rating", "9", "--watched", "2020-05-01")
+	if code != exitOK {
+		t.Fatalf("set: got exit code %d\n%s", code, stderr)
+	}
+	code, out, _ := runCommand("library", "list", "--library", lib, "--watched")
+	if code != exitOK || !strings.Contains(out, "Inception") || strings.Contains(out, "Guardians") || !strings.Contains(out, "2020-05-01") {
+		t.Errorf("list: code %d output:\n%s", code, out)
+	}
+	if code, _, _ := runCommand("library", "set", "tt1375666", "--library", lib, "--rating", "11"); code != exitUsage {
+		t.Errorf("bad rating: got exit code %d", code)
+	}
+
+	export := filepath.Join(dir, "export.csv")
+	if code, _, stderr := runCommand("library", "export", export, "--library", lib); code != exitOK {
+		t.Fatalf("export: got exit code %d\n%s", code, stderr)
+	}
+	if code, _, _ := runCommand("library", "remove", "tt1375666", "--library", lib); code != exitOK {
+		t.Errorf("remove: got exit code %d", code)
+	}
+	if code, _, _ := runCommand("library", "remove", "tt1375666", "--library", lib); code != exitNotFound {
+		t.Errorf("remove twice: got exit code %d", code)
+	}
+	if code, _, stderr := runCommand("library", "import", export, "--library", lib); code != exitOK {
+		t.Fatalf("import: got exit code %d\n%s", code, stderr)
+	}
+	code, out, _ = runCommand("library", "list", "--library", lib, "--my-rating", "9", "-o", "csv")
+	if code != exitOK || !strings.Contains(out, "tt1375666") || !strings.Contains(out, "watchlist") {
+		t.Errorf("list after import: code %d output:\n%s", code, out)
+	}
+	code, out, _ = runCommand("library", "search", "--library", lib, "dreams", "-o", "csv")
+	if code != exitOK || !strings.HasPrefix(out, "imdbID,Title,Year,Score,Fields\ntt1375666,Inception,2010,") {
+		t.Errorf("search: code %d output:\n%s", code, out)
+	}
+	code, out, _ = runCommand("recommend", "tt1375666", "--library", lib)
+	if code != exitOK || !strings.Contains(out, "tt3896198") || !strings.Contains(out, "genre: Action, Adventure") {
+		t.Errorf("recommend: code %d output:\n%s", code, out)
+	}
+	code, out, _ = runCommand("stats", "--library", lib, "--by", "genre,director")
+	if code != exitOK || !strings.Contains(out, "2 movies, 2 rated, average imdbRating 8.20") ||
+		!strings.Contains(out, "Christopher Nolan") || !strings.Contains(out, "Director") || !strings.Contains(out, "###") || strings.Contains(out, "By decade") {
+		t.Errorf("stats: code %d output:\n%s", code, out)
+	}
+	code, out, _ = runCommand("stats", "--library", lib, "--by", "rated", "-o", "csv")
+	if code != exitOK || out != "Dimension,Name,Movies,imdbRating,RuntimeMinutes\nrated,PG-13,2,8.20,284\n" {
+		t.Errorf("stats csv: code %d output:\n%s", code, out)
+	}
+	if code, _, _ := runCommand("stats", "--library", lib, "--by", "studio"); code != exitUsage {
+		t.Errorf("stats --by studio: got exit code %d", code)
+	}
+}
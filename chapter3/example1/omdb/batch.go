@@ -0,0 +1,171 @@
+package omdb
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Number of concurrent lookups when BatchOptions.Workers is zero
+const DefaultBatchWorkers = 4
+
+var imdbIDPattern = regexp.MustCompile(`^tt\d{7,}$`)
+
+// Report if s look like an IMDb id, example: tt0944947
+func IsImdbID(s string) bool {
+	return imdbIDPattern.MatchString(s)
+}
+
+type BatchOptions struct {
+	// Number of concurrent lookups
+	Workers int
+	// Send the results in input order instead of completion order
+	Ordered bool
+	// Optional, return true for the inputs to leave out, used to resume a batch
+	Skip func(index int) bool
+	// Optional, replace the lookup of the queries, index is the position of the query
+	Lookup func(ctx context.Context, index int, query string) (*MovieInfo, error)
+}
+
+// Result of one lookup of a batch. Index is the position of the query in
+// the input, starting at 0. Err is set when the lookup failed.
+type BatchResult struct {
+	Index int
+	Query string
+	Movie *MovieInfo
+	Err   error
+
+	skipped bool
+}
+
+// Look up every query received on the channel, IMDb ids with SearchById
+// and anything else with SearchByName, over a pool of workers.
+// The returned channel is closed once the queries channel is closed and
+// every lookup is done, or when the context is done.
+func (c *Client) Batch(ctx context.Context, queries <-chan string, opts BatchOptions) <-chan BatchResult {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = DefaultBatchWorkers
+	}
+	jobs := make(chan BatchResult)
+	done := make(chan BatchResult, workers)
+	go func() {
+		defer close(jobs)
+		index := 0
+		for query := range queries {
+			job := BatchResult{Index: index, Query: query}
+			job.skipped = opts.Skip != nil && opts.Skip(index)
+			index++
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				switch {
+				case job.skipped:
+				case opts.Lookup != nil:
+					job.Movie, job.Err = opts.Lookup(ctx, job.Index, job.Query)
+				default:
+					job.Movie, job.Err = c.lookup(ctx, job.Query)
+				}
+				done <- job
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	results := make(chan BatchResult)
+	go func() {
+		defer close(results)
+		if opts.Ordered {
+			sendOrdered(ctx, done, results)
+			return
+		}
+		for result := range done {
+			if !result.skipped && !send(ctx, results, result) {
+				break
+			}
+		}
+		for range done {
+		}
+	}()
+	return results
+}
+
+func (c *Client) lookup(ctx context.Context, query string) (*MovieInfo, error) {
+	if IsImdbID(query) {
+		return c.SearchById(ctx, query)
+	}
+	return c.SearchByName(ctx, query)
+}
+
+func send(ctx context.Context, results chan<- BatchResult, result BatchResult) bool {
+	select {
+	case results <- result:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Hold the results completed early until the ones before them are sent
+func sendOrdered(ctx context.Context, done <-chan BatchResult, results chan<- BatchResult) {
+	pending := map[int]BatchResult{}
+	next := 0
+	for result := range done {
+		pending[result.Index] = result
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if !r.skipped && !send(ctx, results, r) {
+				for range done {
+				}
+				return
+			}
+		}
+	}
+}
+
+// Send the lines of r on the returned channel, skipping blank lines and
+// lines starting with #. The error function report the read error, if
+// any, once the channel is closed.
+func ReadQueries(ctx context.Context, r io.Reader) (<-chan string, func() error) {
+	queries := make(chan string)
+	var err error
+	go func() {
+		defer close(queries)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			select {
+			case queries <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		err = scanner.Err()
+	}()
+	return queries, func() error { return err }
+}
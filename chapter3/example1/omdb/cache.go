@@ -0,0 +1,191 @@
+package omdb
+
+import (
+	"container/list"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Time a successful answer stay in the cache when Client.CacheTTL is zero
+const DefaultCacheTTL = 24 * time.Hour
+
+// Time a not found answer stay in the cache when Client.NegativeTTL is zero
+const DefaultNegativeTTL = time.Hour
+
+// Store raw API answers keyed by normalized query.
+// A ttl <= 0 mean the entry never expire, a zero expires time mean the same.
+type Cache interface {
+	Get(key string) (value []byte, expires time.Time, ok bool)
+	Set(key string, value []byte, ttl time.Duration)
+	Stats() CacheStats
+}
+
+type CacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+}
+
+// Counters shared by the cache implementations
+type cacheCounters struct {
+	hits, misses, evictions int64
+}
+
+func (cc *cacheCounters) hit()   { atomic.AddInt64(&cc.hits, 1) }
+func (cc *cacheCounters) miss()  { atomic.AddInt64(&cc.misses, 1) }
+func (cc *cacheCounters) evict() { atomic.AddInt64(&cc.evictions, 1) }
+
+func (cc *cacheCounters) Stats() CacheStats {
+	return CacheStats{
+		Hits:      atomic.LoadInt64(&cc.hits),
+		Misses:    atomic.LoadInt64(&cc.misses),
+		Evictions: atomic.LoadInt64(&cc.evictions),
+	}
+}
+
+func expiresAt(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+func expired(expires time.Time) bool {
+	return !expires.IsZero() && time.Now().After(expires)
+}
+
+// In memory cache keeping at most capacity entries, the least recently
+// used entry is evicted first. Expired entries are evicted when read.
+type LRUCache struct {
+	cacheCounters
+	capacity int
+	mutex    sync.Mutex
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func NewLRUCache(capacity int) *LRUCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &LRUCache{capacity: capacity, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (lru *LRUCache) Get(key string) ([]byte, time.Time, bool) {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+	elem, ok := lru.entries[key]
+	if !ok {
+		lru.miss()
+		return nil, time.Time{}, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if expired(entry.expires) {
+		lru.remove(elem)
+		lru.miss()
+		return nil, time.Time{}, false
+	}
+	lru.order.MoveToFront(elem)
+	lru.hit()
+	return entry.value, entry.expires, true
+}
+
+func (lru *LRUCache) Set(key string, value []byte, ttl time.Duration) {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+	entry := &lruEntry{key: key, value: value, expires: expiresAt(ttl)}
+	if elem, ok := lru.entries[key]; ok {
+		elem.Value = entry
+		lru.order.MoveToFront(elem)
+		return
+	}
+	lru.entries[key] = lru.order.PushFront(entry)
+	for lru.order.Len() > lru.capacity {
+		lru.remove(lru.order.Back())
+	}
+}
+
+// Number of entries in the cache, expired or not
+func (lru *LRUCache) Len() int {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+	return lru.order.Len()
+}
+
+func (lru *LRUCache) remove(elem *list.Element) {
+	lru.order.Remove(elem)
+	delete(lru.entries, elem.Value.(*lruEntry).key)
+	lru.evict()
+}
+
+// Two level cache, usually an LRUCache in front of a DiskCache.
+// Entries found in the back cache are copied to the front cache.
+type TieredCache struct {
+	cacheCounters
+	Front, Back Cache
+}
+
+func NewTieredCache(front, back Cache) *TieredCache {
+	return &TieredCache{Front: front, Back: back}
+}
+
+func (tc *TieredCache) Get(key string) ([]byte, time.Time, bool) {
+	if value, expires, ok := tc.Front.Get(key); ok {
+		tc.hit()
+		return value, expires, true
+	}
+	value, expires, ok := tc.Back.Get(key)
+	if !ok {
+		tc.miss()
+		return nil, time.Time{}, false
+	}
+	ttl := time.Duration(0)
+	if !expires.IsZero() {
+		// Expired since the back cache checked, a zero ttl would keep it
+		// forever in the front cache
+		if ttl = time.Until(expires); ttl <= 0 {
+			tc.miss()
+			return nil, time.Time{}, false
+		}
+	}
+	tc.Front.Set(key, value, ttl)
+	tc.hit()
+	return value, expires, true
+}
+
+func (tc *TieredCache) Set(key string, value []byte, ttl time.Duration) {
+	tc.Front.Set(key, value, ttl)
+	tc.Back.Set(key, value, ttl)
+}
+
+// Hits and misses of the tiered cache, evictions of both levels
+func (tc *TieredCache) Stats() CacheStats {
+	stats := tc.cacheCounters.Stats()
+	stats.Evictions = tc.Front.Stats().Evictions + tc.Back.Stats().Evictions
+	return stats
+}
+
+// Build the cache key of a query: the API key is left out, the
+// parameters are sorted and the values lower cased with the
+// white spaces collapsed, so "Game  Of" and "game of" share an entry.
+func cacheKey(parms url.Values) string {
+	normalized := url.Values{}
+	for name, values := range parms {
+		if name == "apikey" {
+			continue
+		}
+		for _, value := range values {
+			normalized.Add(name, strings.ToLower(strings.Join(strings.Fields(value), " ")))
+		}
+	}
+	return normalized.Encode()
+}
@@ -0,0 +1,106 @@
+package omdb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache storing one file per entry in a directory so answers
+// survive between runs. The file name is the sha256 of the key.
+type DiskCache struct {
+	cacheCounters
+	Dir string
+}
+
+type diskEntry struct {
+	Key     string    `json:"key"`
+	Expires time.Time `json:"expires"`
+	Value   []byte    `json:"value"`
+}
+
+// Create the directory if needed
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &DiskCache{Dir: dir}, nil
+}
+
+func (dc *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dc.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (dc *DiskCache) Get(key string) ([]byte, time.Time, bool) {
+	path := dc.path(key)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		dc.miss()
+		return nil, time.Time{}, false
+	}
+	entry := diskEntry{}
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		dc.miss()
+		return nil, time.Time{}, false
+	}
+	if expired(entry.Expires) {
+		os.Remove(path)
+		dc.evict()
+		dc.miss()
+		return nil, time.Time{}, false
+	}
+	dc.hit()
+	return entry.Value, entry.Expires, true
+}
+
+// Errors are ignored, a failed write only cost a future miss
+func (dc *DiskCache) Set(key string, value []byte, ttl time.Duration) {
+	data, err := json.Marshal(diskEntry{Key: key, Expires: expiresAt(ttl), Value: value})
+	if err != nil {
+		return
+	}
+	// A temporary file of its own for each Set, concurrent Sets of the same
+	// key must not write to the same file
+	tmp, err := ioutil.TempFile(dc.Dir, ".cache-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dc.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// Remove the expired entries, return how many were removed
+func (dc *DiskCache) Prune() (int, error) {
+	files, err := filepath.Glob(filepath.Join(dc.Dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, path := range files {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		entry := diskEntry{}
+		if json.Unmarshal(data, &entry) != nil || expired(entry.Expires) {
+			if os.Remove(path) == nil {
+				dc.evict()
+				removed++
+			}
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,122 @@
+package omdb
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLRUCacheEviction(t *testing.T) {
+	lru := NewLRUCache(2)
+	lru.Set("a", []byte("1"), 0)
+	lru.Set("b", []byte("2"), 0)
+	lru.Get("a")
+	lru.Set("c", []byte("3"), 0)
+	if _, _, ok := lru.Get("b"); ok {
+		t.Error("least recently used entry b was not evicted")
+	}
+	if _, _, ok := lru.Get("a"); !ok {
+		t.Error("entry a was evicted")
+	}
+	lru.Set("d", []byte("4"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, _, ok := lru.Get("d"); ok {
+		t.Error("expired entry d was returned")
+	}
+	if stats := lru.Stats(); stats.Hits != 2 || stats.Misses != 2 || stats.Evictions != 3 {
+		t.Errorf("got stats %+v", stats)
+	}
+}
+
+func TestTieredCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "omdb-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	disk, err := NewDiskCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	disk.Set("i=tt3896198", []byte("body"), time.Hour)
+
+	tc := NewTieredCache(NewLRUCache(10), disk)
+	value, _, ok := tc.Get("i=tt3896198")
+	if !ok || string(value) != "body" {
+		t.Fatalf("got %q %v", value, ok)
+	}
+	if _, _, ok := tc.Front.Get("i=tt3896198"); !ok {
+		t.Error("entry not promoted to the front cache")
+	}
+
+	// An entry expiring between the checks of the back cache and the promotion
+	tc = NewTieredCache(NewLRUCache(10), &staleCache{})
+	if _, _, ok := tc.Get("i=tt3896198"); ok {
+		t.Error("got an expired entry")
+	}
+	if _, _, ok := tc.Front.Get("i=tt3896198"); ok {
+		t.Error("expired entry promoted to the front cache")
+	}
+
+	// Concurrent Sets of the same key
+	done := make(chan bool)
+	for i := 0; i < 10; i++ {
+		go func() {
+			disk.Set("i=tt1375666", []byte("other body"), time.Hour)
+			done <- true
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		<-done
+	}
+	if value, _, ok := disk.Get("i=tt1375666"); !ok || string(value) != "other body" {
+		t.Errorf("got %q %v after concurrent sets", value, ok)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 2 {
+		t.Errorf("got %d files in the cache directory, want 2", len(files))
+	}
+}
+
+// Back cache returning entries that already expired
+type staleCache struct{ cacheCounters }
+
+func (*staleCache) Get(key string) ([]byte, time.Time, bool) {
+	return []byte("body"), time.Now().Add(-time.Second), true
+}
+
+func (*staleCache) Set(key string, value []byte, ttl time.Duration) {}
+
+func TestClientCache(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("i") == "tt0000000" {
+			w.Write([]byte(`{"Response":"False","Error":"Incorrect IMDb ID."}`))
+			return
+		}
+		w.Write([]byte(`{"Title":"Guardians of the Galaxy Vol. 2","imdbID":"tt3896198","Response":"True"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, srv.Client(), "secret", "")
+	c.Cache = NewLRUCache(10)
+	for i := 0; i < 3; i++ {
+		if _, err := c.SearchById(context.Background(), "tt3896198"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.SearchById(context.Background(), "tt0000000"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("got %v", err)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("got %d requests, want 2", requests)
+	}
+	if stats := c.Cache.Stats(); stats.Hits != 4 || stats.Misses != 2 {
+		t.Errorf("got stats %+v", stats)
+	}
+}
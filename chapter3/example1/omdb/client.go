@@ -0,0 +1,276 @@
+package omdb
+
+/*
+Client for the omdbapi.com REST API using only build-in packages.
+*/
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Base URL of the public omdbapi.com API
+const DefaultBaseURL = "http://www.omdbapi.com/"
+
+// Environment variable read for the API key when none is given to NewClient
+const APIKeyEnv = "OMDB_API_KEY"
+
+const DefaultUserAgent = "omdb-go-client/1.0"
+
+// Client talk to an OMDb compatible API.
+// Fields can be changed after NewClient but not while the client is in use.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	APIKey     string
+	UserAgent  string
+
+	// Optional cache of the raw answers. CacheTTL and NegativeTTL
+	// default to DefaultCacheTTL and DefaultNegativeTTL when zero,
+	// a negative NegativeTTL disable the caching of not found answers.
+	Cache       Cache
+	CacheTTL    time.Duration
+	NegativeTTL time.Duration
+
+	// Retries of failed requests, no retry by default
+	Retry RetryPolicy
+	// Optional client side limit of the request rate
+	Limiter *RateLimiter
+
+	// Format requested, FormatJSON when empty or FormatXML. The answers are
+	// decoded according to their Content-Type whatever the format asked.
+	Format string
+
+	// Optional keys used in turn instead of APIKey
+	Keys *KeyPool
+}
+
+// Create a new Client. Empty values fall back to the defaults:
+// DefaultBaseURL, an http.Client with a 30 seconds timeout,
+// the OMDB_API_KEY environment variable and DefaultUserAgent.
+func NewClient(baseURL string, httpClient *http.Client, apiKey, userAgent string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if apiKey == "" {
+		apiKey = os.Getenv(APIKeyEnv)
+	}
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	return &Client{BaseURL: baseURL, HTTPClient: httpClient, APIKey: apiKey, UserAgent: userAgent}
+}
+
+// Parameters of an exact title lookup, only Title is required.
+// Type is one of movie, series or episode, Plot is short or full.
+type TitleQuery struct {
+	Title string
+	Year  string
+	Type  string
+	Plot  string
+}
+
+func (q TitleQuery) values() url.Values {
+	parms := url.Values{}
+	parms.Set("t", q.Title)
+	if q.Year != "" {
+		parms.Set("y", q.Year)
+	}
+	if q.Type != "" {
+		parms.Set("type", q.Type)
+	}
+	if q.Plot != "" {
+		parms.Set("plot", q.Plot)
+	}
+	return parms
+}
+
+func (c *Client) SearchByName(ctx context.Context, name string) (*MovieInfo, error) {
+	return c.SearchByTitle(ctx, TitleQuery{Title: name})
+}
+
+// Exact title lookup with the optional year, type and plot filters
+func (c *Client) SearchByTitle(ctx context.Context, q TitleQuery) (*MovieInfo, error) {
+	mi := &MovieInfo{}
+	if err := c.get(ctx, q.values(), mi); err != nil {
+		return nil, err
+	}
+	return mi, nil
+}
+
+func (c *Client) SearchById(ctx context.Context, id string) (*MovieInfo, error) {
+	parms := url.Values{}
+	parms.Set("i", id)
+	mi := &MovieInfo{}
+	if err := c.get(ctx, parms, mi); err != nil {
+		return nil, err
+	}
+	return mi, nil
+}
+
+// Send the query and decode the body into v with the decoder of its Content-Type.
+// An error payload is returned as an *APIError.
+func (c *Client) get(ctx context.Context, parms url.Values, v interface{}) error {
+	if c.Format != "" && c.Format != FormatJSON {
+		parms.Set("r", c.Format)
+	}
+	key := cacheKey(parms)
+	if c.Cache != nil {
+		if body, _, ok := c.Cache.Get(key); ok {
+			return decode(DecoderFor("", string(body)), string(body), v)
+		}
+	}
+	body, contentType, err := c.send(ctx, parms)
+	var statusErr *APIError
+	if err != nil && !errors.As(err, &statusErr) {
+		return err
+	}
+	decoder := DecoderFor(contentType, body)
+	if apiErr := decoder.ResponseError(body); apiErr != nil {
+		if statusErr != nil {
+			apiErr.StatusCode, apiErr.Status = statusErr.StatusCode, statusErr.Status
+		}
+		if c.Cache != nil && c.NegativeTTL >= 0 && errors.Is(apiErr, ErrNotFound) {
+			c.Cache.Set(key, []byte(body), ttlOrDefault(c.NegativeTTL, DefaultNegativeTTL))
+		}
+		return apiErr
+	}
+	if statusErr != nil {
+		return statusErr
+	}
+	if err := decoder.Decode(body, v); err != nil {
+		return err
+	}
+	if c.Cache != nil {
+		c.Cache.Set(key, []byte(body), ttlOrDefault(c.CacheTTL, DefaultCacheTTL))
+	}
+	return nil
+}
+
+// Decode a body that was cached, the cache does not keep the Content-Type
+func decode(decoder Decoder, body string, v interface{}) error {
+	if apiErr := decoder.ResponseError(body); apiErr != nil {
+		return apiErr
+	}
+	return decoder.Decode(body, v)
+}
+
+func ttlOrDefault(ttl, def time.Duration) time.Duration {
+	if ttl == 0 {
+		return def
+	}
+	return ttl
+}
+
+// Build the request URL from the base URL, the API key and the query parameters
+func (c *Client) requestURL(parms url.Values, apiKey string) string {
+	parms.Set("apikey", apiKey)
+	siteURL := c.BaseURL
+	if strings.Contains(siteURL, "?") {
+		return siteURL + "&" + parms.Encode()
+	}
+	return siteURL + "?" + parms.Encode()
+}
+
+// Send the request with APIKey, or with the keys of the pool in turn until
+// one is neither over its limit nor invalid.
+func (c *Client) send(ctx context.Context, parms url.Values) (string, string, error) {
+	if c.Keys == nil {
+		return c.sendGetRequest(ctx, parms, c.APIKey)
+	}
+	for {
+		key, err := c.Keys.acquire()
+		if err != nil {
+			return "", "", err
+		}
+		body, contentType, err := c.sendGetRequest(ctx, parms, key)
+		apiErr := DecoderFor(contentType, body).ResponseError(body)
+		switch {
+		case apiErr == nil:
+			return body, contentType, err
+		case errors.Is(apiErr, ErrRequestLimitReached):
+			c.Keys.exhausted(key)
+		case errors.Is(apiErr, ErrInvalidAPIKey):
+			c.Keys.invalid(key)
+		default:
+			return body, contentType, err
+		}
+	}
+}
+
+// Send the request, retrying as configured by c.Retry.
+// Return the body and its Content-Type.
+func (c *Client) sendGetRequest(ctx context.Context, parms url.Values, apiKey string) (string, string, error) {
+	siteURL := c.requestURL(parms, apiKey)
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && c.Keys != nil {
+			c.Keys.use(apiKey)
+		}
+		body, contentType, wait, err := c.doRequest(ctx, siteURL)
+		if err == nil || attempt >= c.Retry.MaxAttempts || !retryable(ctx, err) || wait > c.Retry.maxDelay() {
+			return body, contentType, err
+		}
+		if delay := c.Retry.backoff(attempt); delay > wait {
+			wait = delay
+		}
+		if sleepErr := sleep(ctx, wait); sleepErr != nil {
+			return body, contentType, err
+		}
+	}
+}
+
+// Send one request, return the body, its Content-Type and the Retry-After delay of the answer
+func (c *Client) doRequest(ctx context.Context, siteURL string) (string, string, time.Duration, error) {
+	if c.Limiter != nil {
+		if err := c.Limiter.Wait(ctx); err != nil {
+			return "", "", 0, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, siteURL, nil)
+	if err != nil {
+		return "", "", 0, err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", "", 0, redactAPIKey(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if resp.StatusCode != http.StatusOK {
+		return string(body), contentType, retryAfter(resp), &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Message: resp.Status, Body: string(body)}
+	}
+	return string(body), contentType, 0, nil
+}
+
+// Remove the API key from the URL of a transport error so it does not end up in logs
+func redactAPIKey(err error) error {
+	urlErr, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+	u, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil {
+		return err
+	}
+	parms := u.Query()
+	if parms.Get("apikey") != "" {
+		parms.Set("apikey", "REDACTED")
+		u.RawQuery = parms.Encode()
+	}
+	return &url.Error{Op: urlErr.Op, URL: u.String(), Err: urlErr.Err}
+}
@@ -0,0 +1,342 @@
+package omdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb/omdbtest"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearchById(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("apikey") != "secret" || q.Get("i") != "tt3896198" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		if r.UserAgent() != "test-agent" {
+			t.Errorf("unexpected user agent %q", r.UserAgent())
+		}
+		w.Write([]byte(`{"Title":"Guardians of the Galaxy Vol. 2","imdbID":"tt3896198"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, srv.Client(), "secret", "test-agent")
+	mi, err := c.SearchById(context.Background(), "tt3896198")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mi.Title != "Guardians of the Galaxy Vol. 2" {
+		t.Errorf("got title %q", mi.Title)
+	}
+}
+
+func TestAPIKeyFromEnv(t *testing.T) {
+	old := os.Getenv(APIKeyEnv)
+	defer os.Setenv(APIKeyEnv, old)
+	os.Setenv(APIKeyEnv, "from-env")
+	if c := NewClient("", nil, "", ""); c.APIKey != "from-env" {
+		t.Errorf("got key %q", c.APIKey)
+	}
+}
+
+func TestKeyPool(t *testing.T) {
+	srv := omdbtest.MustNewServer(omdbtest.FixtureDir())
+	defer srv.Close()
+	srv.SetAPIKeys("k1-aaaa", "k2-bbbb", "k3-cccc")
+	srv.SetQuota(2)
+	dir, err := ioutil.TempDir("", "keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "usage.json")
+
+	pool, err := LoadKeyPool(path, 3, "invalid-key", "k1-aaaa", "k2-bbbb", "k3-cccc", "k1-aaaa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.SetLimit("k3-cccc", 1)
+	c := NewClient(srv.URL, srv.Client(), "", "")
+	c.Keys = pool
+	for i := 0; i < 5; i++ {
+		if _, err := c.SearchById(context.Background(), "tt1375666"); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	if _, err := c.SearchById(context.Background(), "tt1375666"); !errors.Is(err, ErrRequestLimitReached) {
+		t.Errorf("got %v, want ErrRequestLimitReached", err)
+	}
+	if n := srv.Requests(); n != 8 {
+		t.Errorf("got %d requests, want 8", n)
+	}
+	summary := func(usage []KeyUsage) string {
+		list := []string{}
+		for _, u := range usage {
+			list = append(list, fmt.Sprintf("%s %d/%d %d %v %v", u.Key, u.Used, u.Limit, u.Remaining, u.Exhausted, u.Invalid))
+		}
+		return strings.Join(list, ", ")
+	}
+	want := "in****ey 1/3 2 false true, k1****aa 3/3 0 true false, k2****bb 3/3 0 true false, k3****cc 1/1 0 false false"
+	if got := summary(pool.Usage()); got != want {
+		t.Errorf("got usage %s\nwant %s", got, want)
+	}
+
+	// The counts survive a restart and are reset the next day
+	if err := pool.Save(); err != nil {
+		t.Fatal(err)
+	}
+	pool, err = LoadKeyPool(path, 0, "k1-aaaa", "k2-bbbb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := summary(pool.Usage()); got != "k1****aa 3/0 -1 true false, k2****bb 3/0 -1 true false" {
+		t.Errorf("after reload: got %s", got)
+	}
+	pool.now = func() time.Time { return time.Now().Add(24 * time.Hour) }
+	if got := summary(pool.Usage()); got != "k1****aa 0/0 -1 false false, k2****bb 0/0 -1 false false" {
+		t.Errorf("next day: got %s", got)
+	}
+
+	c.Keys = NewKeyPool(0, "wrong")
+	if _, err := c.SearchById(context.Background(), "tt1375666"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("got %v, want ErrInvalidAPIKey", err)
+	}
+}
+
+func TestKeyPoolSaves(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "usage.json")
+	pool, err := LoadKeyPool(path, 3, "k1-aaaa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+	used := func() int {
+		saved, err := LoadKeyPool(path, 3, "k1-aaaa")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return saved.Usage()[0].Used
+	}
+
+	// The first request is saved, the next one waits for the interval
+	pool.acquire()
+	pool.acquire()
+	if n := used(); n != 1 {
+		t.Errorf("got %d saved requests, want 1", n)
+	}
+	// Reaching the limit is saved at once
+	pool.acquire()
+	if n := used(); n != 3 {
+		t.Errorf("at the limit: got %d saved requests, want 3", n)
+	}
+	pool.use("k1-aaaa")
+	now = now.Add(keyPoolSaveInterval)
+	pool.use("k1-aaaa")
+	if n := used(); n != 5 {
+		t.Errorf("after the interval: got %d saved requests, want 5", n)
+	}
+	pool.use("k1-aaaa")
+	if err := pool.Save(); err != nil || used() != 6 {
+		t.Errorf("after Save: got %d saved requests %v, want 6", used(), err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("got %d files, want only the usage file", len(files))
+	}
+}
+
+func TestContextCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := NewClient(srv.URL, srv.Client(), "secret", "")
+	if _, err := c.SearchByName(ctx, "Game of"); err == nil {
+		t.Fatal("expected error for canceled context")
+	}
+}
+
+func TestSearchIterator(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("s") != "Game of" || q.Get("type") != "series" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		switch q.Get("page") {
+		case "":
+			w.Write([]byte(`{"Search":[{"Title":"A","imdbID":"tt1"},{"Title":"B","imdbID":"tt2"},{"Title":"C","imdbID":"tt3"},{"Title":"D","imdbID":"tt4"},{"Title":"E","imdbID":"tt5"},{"Title":"F","imdbID":"tt6"},{"Title":"G","imdbID":"tt7"},{"Title":"H","imdbID":"tt8"},{"Title":"I","imdbID":"tt9"},{"Title":"J","imdbID":"tt10"}],"totalResults":"12","Response":"True"}`))
+		case "2":
+			w.Write([]byte(`{"Search":[{"Title":"K","imdbID":"tt11"},{"Title":"L","imdbID":"tt12"}],"totalResults":"12","Response":"True"}`))
+		default:
+			t.Errorf("unexpected page %s", q.Get("page"))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, srv.Client(), "secret", "")
+	results, err := c.SearchAll(context.Background(), SearchQuery{Query: "Game of", Type: "series"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 12 || results[11].ImdbID != "tt12" {
+		t.Errorf("got %d results: %+v", len(results), results)
+	}
+}
+
+func TestResponseFalse(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   error
+	}{
+		{http.StatusOK, `{"Response":"False","Error":"Movie not found!"}`, ErrNotFound},
+		{http.StatusUnauthorized, `{"Response":"False","Error":"Invalid API key!"}`, ErrInvalidAPIKey},
+		{http.StatusUnauthorized, `{"Response":"False","Error":"Request limit reached!"}`, ErrRequestLimitReached},
+		{http.StatusOK, `{"Response":"False","Error":"Too many results."}`, ErrTooManyResults},
+	}
+	for _, test := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+			w.Write([]byte(test.body))
+		}))
+		c := NewClient(srv.URL, srv.Client(), "secret", "")
+		_, err := c.SearchByName(context.Background(), "Game of")
+		srv.Close()
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: got %v, want %v", test.body, err, test.want)
+		}
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Body != test.body {
+			t.Errorf("%s: body not kept on %#v", test.body, err)
+		}
+	}
+}
+
+func TestGetAllSeasons(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("i") != "tt0944947" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"Title":"Game of Thrones","Season":"` + q.Get("Season") + `","totalSeasons":"3","Episodes":[{"Title":"Winter Is Coming","Released":"2011-04-17","Episode":"1","imdbRating":"8.9","imdbID":"tt1480055"}],"Response":"True"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, srv.Client(), "secret", "")
+	seasons, err := c.GetAllSeasons(context.Background(), "tt0944947")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seasons) != 3 || seasons[2].Season != 3 {
+		t.Fatalf("got %+v", seasons)
+	}
+	if ep := seasons[0].Episodes[0]; ep.Episode != 1 || ep.Released.Year() != 2011 || ep.ImdbRating != 8.9 {
+		t.Errorf("got episode %+v", ep)
+	}
+}
+
+func TestBatchOrdered(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("t") == "missing" {
+			w.Write([]byte(`{"Response":"False","Error":"Movie not found!"}`))
+			return
+		}
+		w.Write([]byte(`{"Title":"` + q.Get("t") + q.Get("i") + `","Response":"True"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, srv.Client(), "secret", "")
+	queries, readErr := ReadQueries(context.Background(), strings.NewReader("tt0944947\n\n# comment\nmissing\nInception\nAlien\n"))
+	results := c.Batch(context.Background(), queries, BatchOptions{
+		Workers: 3,
+		Ordered: true,
+		Skip:    func(index int) bool { return index == 3 },
+	})
+	got := []string{}
+	for result := range results {
+		if result.Err != nil {
+			got = append(got, strconv.Itoa(result.Index)+":error")
+			continue
+		}
+		got = append(got, strconv.Itoa(result.Index)+":"+result.Movie.Title)
+	}
+	if err := readErr(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"0:tt0944947", "1:error", "2:Inception"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	srv := omdbtest.MustNewServer(omdbtest.FixtureDir())
+	defer srv.Close()
+	c := NewClient(srv.URL, srv.Client(), omdbtest.APIKey, "")
+
+	tests := []struct {
+		title string
+		opts  ResolveOptions
+		want  string
+		exact bool
+	}{
+		{"inception", ResolveOptions{}, "tt1375666", true},
+		{"Game of", ResolveOptions{}, "tt0944947", false},
+		{"guardians of the galaxi", ResolveOptions{}, "tt2015381", false},
+		{"Guardians of the Galaxy 2", ResolveOptions{Year: 2017}, "tt3896198", false},
+		{"dark knigth", ResolveOptions{}, "tt0468569", false},
+	}
+	for _, test := range tests {
+		res, err := c.Resolve(context.Background(), test.title, test.opts)
+		if err != nil {
+			t.Errorf("%q: %v", test.title, err)
+			continue
+		}
+		if res.Movie.ImdbID != test.want || res.Exact != test.exact || res.Confidence <= 0 || res.Confidence > 1 {
+			t.Errorf("%q: got %s exact %v confidence %.2f, want %s", test.title, res.Movie.ImdbID, res.Exact, res.Confidence, test.want)
+		}
+	}
+
+	_, err := c.Resolve(context.Background(), "Guardians", ResolveOptions{})
+	var ambiguous *AmbiguousError
+	if !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 2 {
+		t.Errorf("got %v, want an ambiguous error with 2 candidates", err)
+	}
+	if _, err := c.Resolve(context.Background(), "No Such Movie", ResolveOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
+
+func TestTitleSimilarity(t *testing.T) {
+	tests := []struct {
+		query, title string
+		want         float64
+	}{
+		{"inception", "Inception", 1},
+		{"the dark knight", "Dark Knight", 1},
+		{"game of", "Game of Thrones", 0.9},
+		{"incepton", "Inception", 1 - 1.0/9},
+		{"alien", "Inception", 1 - 7.0/9},
+	}
+	for _, test := range tests {
+		got := titleSimilarity(normalizeTitle(test.query), normalizeTitle(test.title))
+		if got < test.want-1e-9 || got > test.want+1e-9 {
+			t.Errorf("%q, %q: got %.3f, want %.3f", test.query, test.title, got, test.want)
+		}
+	}
+}
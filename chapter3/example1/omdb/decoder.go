@@ -0,0 +1,208 @@
+package omdb
+
+/*
+omdbapi.com answer in JSON or, with r=xml, in XML where the values are the
+attributes of the elements:
+
+	<root response="True">
+	  <movie title="Inception" year="2010" imdbID="tt1375666" ... />
+	</root>
+
+	<root totalResults="2" response="True">
+	  <result title="Guardians of the Galaxy" year="2014" imdbID="tt2015381" ... />
+	</root>
+
+	<root response="False"><error>Movie not found!</error></root>
+
+MovieInfo, SearchPage and Season implement xml.Unmarshaler for these
+documents, the XML attributes are mapped to the JSON names so both formats
+give the same values. The ratings of other sources are not in the XML.
+*/
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"mime"
+	"strings"
+)
+
+// Formats of Client.Format
+const (
+	FormatJSON = "json"
+	FormatXML  = "xml"
+)
+
+// Decode the answers of omdbapi.com in one format
+type Decoder interface {
+	// Return an *APIError if the body is an error payload, nil otherwise
+	ResponseError(body string) *APIError
+	Decode(body string, v interface{}) error
+}
+
+var (
+	JSONDecoder Decoder = jsonDecoder{}
+	XMLDecoder  Decoder = xmlDecoder{}
+)
+
+// Return the decoder for the Content-Type of an answer. When the type is
+// neither JSON nor XML, for example a cached body without type, the body
+// is looked at: XML start with "<".
+func DecoderFor(contentType, body string) Decoder {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasSuffix(mediaType, "json"):
+		return JSONDecoder
+	case strings.HasSuffix(mediaType, "xml"):
+		return XMLDecoder
+	case strings.HasPrefix(strings.TrimSpace(body), "<"):
+		return XMLDecoder
+	}
+	return JSONDecoder
+}
+
+type jsonDecoder struct{}
+
+func (jsonDecoder) ResponseError(body string) *APIError {
+	return responseError(body)
+}
+
+func (jsonDecoder) Decode(body string, v interface{}) error {
+	return json.Unmarshal([]byte(body), v)
+}
+
+type xmlDecoder struct{}
+
+func (xmlDecoder) ResponseError(body string) *APIError {
+	root := xmlNode{}
+	if err := xml.Unmarshal([]byte(body), &root); err != nil || !strings.EqualFold(root.attr("response"), "False") {
+		return nil
+	}
+	message := ""
+	if e := root.child("error"); e != nil {
+		message = strings.TrimSpace(e.Text)
+	}
+	return &APIError{Message: message, Body: body, Err: sentinelFor(message)}
+}
+
+func (xmlDecoder) Decode(body string, v interface{}) error {
+	return xml.Unmarshal([]byte(body), v)
+}
+
+// Generic XML element, the omdbapi.com documents are small
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Text     string     `xml:",chardata"`
+	Children []xmlNode  `xml:",any"`
+}
+
+func (n *xmlNode) attr(name string) string {
+	for _, attr := range n.Attrs {
+		if strings.EqualFold(attr.Name.Local, name) {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// Return the first child with the name, or nil
+func (n *xmlNode) child(name string) *xmlNode {
+	for i := range n.Children {
+		if n.Children[i].XMLName.Local == name {
+			return &n.Children[i]
+		}
+	}
+	return nil
+}
+
+// Return the attributes named like the JSON fields, ignoring the case.
+// omdbapi.com spell them with a lower case first letter: Title become title.
+func (n *xmlNode) fields(names []string) map[string]interface{} {
+	doc := map[string]interface{}{}
+	for _, attr := range n.Attrs {
+		for _, name := range names {
+			if strings.EqualFold(attr.Name.Local, name) {
+				doc[name] = attr.Value
+			}
+		}
+	}
+	return doc
+}
+
+// JSON names of the attributes of the XML elements
+var (
+	movieAttrs   = append(append([]string{}, FieldNames...), "seriesID", "Season", "Episode")
+	resultAttrs  = []string{"Title", "Year", "imdbID", "Type", "Poster"}
+	seasonAttrs  = []string{"Title", "Season", "totalSeasons"}
+	episodeAttrs = []string{"Title", "Released", "Episode", "imdbRating", "imdbID"}
+)
+
+// Read the element as a generic node
+func readNode(d *xml.Decoder, start xml.StartElement) (*xmlNode, error) {
+	node := &xmlNode{}
+	if err := d.DecodeElement(node, &start); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// Decode the <movie> element, or the <root> element holding it
+func (mi *MovieInfo) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	node, err := readNode(d, start)
+	if err != nil {
+		return err
+	}
+	if node.XMLName.Local != "movie" {
+		if node = node.child("movie"); node == nil {
+			return errors.New("omdb: no <movie> element")
+		}
+	}
+	return remarshal(node.fields(movieAttrs), mi)
+}
+
+// Decode the <root> element of a search, with a <result> element for each movie
+func (p *SearchPage) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	node, err := readNode(d, start)
+	if err != nil {
+		return err
+	}
+	results := []interface{}{}
+	for i := range node.Children {
+		if node.Children[i].XMLName.Local == "result" {
+			results = append(results, node.Children[i].fields(resultAttrs))
+		}
+	}
+	doc := map[string]interface{}{"Search": results}
+	if total := node.attr("totalResults"); total != "" {
+		doc["totalResults"] = total
+	}
+	return remarshal(doc, p)
+}
+
+// Decode the <root> element of a season, with a <result> element for each episode
+func (s *Season) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	node, err := readNode(d, start)
+	if err != nil {
+		return err
+	}
+	doc := node.fields(seasonAttrs)
+	episodes := []interface{}{}
+	for i := range node.Children {
+		if node.Children[i].XMLName.Local == "result" {
+			episodes = append(episodes, node.Children[i].fields(episodeAttrs))
+		}
+	}
+	doc["Episodes"] = episodes
+	return remarshal(doc, s)
+}
+
+// Decode the document with the JSON decoding of v, the XML values being
+// the same strings as the JSON ones
+func remarshal(doc map[string]interface{}, v interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,77 @@
+package omdb
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Sentinel errors for the "Error" messages of a "Response":"False" payload.
+// Use errors.Is to test for them.
+var (
+	ErrNotFound            = errorString("omdb: not found")
+	ErrInvalidAPIKey       = errorString("omdb: invalid API key")
+	ErrRequestLimitReached = errorString("omdb: request limit reached")
+	ErrTooManyResults      = errorString("omdb: too many results")
+)
+
+type errorString string
+
+func (e errorString) Error() string {
+	return string(e)
+}
+
+// Error returned when the API answer with an error, either with a
+// "Response":"False" payload or with a non 200 HTTP status.
+type APIError struct {
+	// HTTP status, example: 401 and "401 Unauthorized"
+	StatusCode int
+	Status     string
+	// "Error" field of the payload or the HTTP status when there is none
+	Message string
+	// Raw body of the response
+	Body string
+	// One of the sentinel errors, nil when the message is unknown
+	Err error
+}
+
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error() + ": " + e.Message
+	}
+	return "omdb: " + e.Message
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// The fields every omdbapi.com answer carry
+type envelope struct {
+	Response string `json:"Response"`
+	Error    string `json:"Error"`
+}
+
+// Return an *APIError if the body is a "Response":"False" payload, nil otherwise
+func responseError(body string) *APIError {
+	env := envelope{}
+	if err := json.Unmarshal([]byte(body), &env); err != nil || !strings.EqualFold(env.Response, "False") {
+		return nil
+	}
+	return &APIError{Message: env.Error, Body: body, Err: sentinelFor(env.Error)}
+}
+
+// Map the messages known to be sent by omdbapi.com to a sentinel error
+func sentinelFor(message string) error {
+	msg := strings.ToLower(message)
+	switch {
+	case strings.Contains(msg, "not found"), strings.Contains(msg, "incorrect imdb id"):
+		return ErrNotFound
+	case strings.Contains(msg, "api key"):
+		return ErrInvalidAPIKey
+	case strings.Contains(msg, "limit reached"):
+		return ErrRequestLimitReached
+	case strings.Contains(msg, "too many results"):
+		return ErrTooManyResults
+	}
+	return nil
+}
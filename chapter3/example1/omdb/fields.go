@@ -0,0 +1,121 @@
+package omdb
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Names of the MovieInfo fields as spelled by omdbapi.com, in display order.
+// Ratings is left out as it is not a single value.
+var FieldNames = []string{
+	"imdbID", "Title", "Year", "Rated", "Released", "Runtime", "Genre",
+	"Director", "Writer", "Actors", "Plot", "Language", "Country", "Awards",
+	"Poster", "Metascore", "imdbRating", "imdbVotes", "Type", "DVD",
+	"BoxOffice", "Production", "Website", "totalSeasons",
+}
+
+// Return the value of a field formatted as omdbapi.com does, with ""
+// instead of "N/A". The name is case insensitive, ok is false for an
+// unknown name.
+func (mi *MovieInfo) Field(name string) (value string, ok bool) {
+	raw := newMovieJSON(mi)
+	return raw.field(name)
+}
+
+// Return the values of the fields in the same order
+func (mi *MovieInfo) Fields(names []string) []string {
+	raw := newMovieJSON(mi)
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i], _ = raw.field(name)
+	}
+	return values
+}
+
+// Report if name is one of FieldNames, ignoring the case
+func IsField(name string) bool {
+	_, ok := CanonicalField(name)
+	return ok
+}
+
+// Return the name of FieldNames matching name, ignoring the case
+func CanonicalField(name string) (string, bool) {
+	for _, field := range FieldNames {
+		if strings.EqualFold(field, name) {
+			return field, true
+		}
+	}
+	return "", false
+}
+
+// Build a movie from values formatted as omdbapi.com does, the reverse of
+// Fields. Unknown names are ignored.
+func MovieFromFields(names, values []string) *MovieInfo {
+	doc := map[string]string{}
+	for i, name := range names {
+		if field, ok := CanonicalField(name); ok && i < len(values) {
+			doc[field] = values[i]
+		}
+	}
+	data, _ := json.Marshal(doc)
+	mi := &MovieInfo{}
+	//Every value is a string, decoding can't fail
+	json.Unmarshal(data, mi)
+	return mi
+}
+
+func (raw *movieJSON) field(name string) (value string, ok bool) {
+	switch strings.ToLower(name) {
+	case "imdbid":
+		value = raw.ImdbID
+	case "title":
+		value = raw.Title
+	case "year":
+		value = raw.Year
+	case "rated":
+		value = raw.Rated
+	case "released":
+		value = raw.Released
+	case "runtime":
+		value = raw.Runtime
+	case "genre":
+		value = raw.Genre
+	case "director":
+		value = raw.Director
+	case "writer":
+		value = raw.Writer
+	case "actors":
+		value = raw.Actors
+	case "plot":
+		value = raw.Plot
+	case "language":
+		value = raw.Language
+	case "country":
+		value = raw.Country
+	case "awards":
+		value = raw.Awards
+	case "poster":
+		value = raw.Poster
+	case "metascore":
+		value = raw.Metascore
+	case "imdbrating":
+		value = raw.ImdbRating
+	case "imdbvotes":
+		value = raw.ImdbVotes
+	case "type":
+		value = raw.Type
+	case "dvd":
+		value = raw.DVD
+	case "boxoffice":
+		value = raw.BoxOffice
+	case "production":
+		value = raw.Production
+	case "website":
+		value = raw.Website
+	case "totalseasons":
+		value = raw.TotalSeasons
+	default:
+		return "", false
+	}
+	return text(value), true
+}
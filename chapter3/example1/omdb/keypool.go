@@ -0,0 +1,291 @@
+package omdb
+
+/*
+A free omdbapi.com key allow 1000 requests a day. A KeyPool spread the
+requests of a Client over several keys: the first key is used until it reach
+its daily limit, or the API answer "Request limit reached!" or "Invalid API
+key!" for it, then the next one.
+
+	pool, err := omdb.LoadKeyPool("usage.json", 1000, "key1", "key2")
+	client.Keys = pool
+
+The usage is counted by UTC day and saved to the file so it survive between
+runs: at once when a key is exhausted, refused or reach its limit, at most
+every keyPoolSaveInterval for the other requests, and by Save, which the
+program call before it exit. The file hold a hash of the keys, not the keys.
+It is not locked, two processes sharing it will overwrite each other's counts.
+*/
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Layout of the days of the usage
+const usageDayLayout = "2006-01-02"
+
+// Least time between two saves of the counts of ordinary requests
+const keyPoolSaveInterval = 5 * time.Second
+
+// Set of API keys with a daily limit each, safe for concurrent use
+type KeyPool struct {
+	// File where the usage is saved, empty to keep it in memory only
+	Path string
+
+	mutex sync.Mutex
+	keys  []*poolKey
+	now   func() time.Time
+	// Time of the last save, and whether counts changed since
+	saved time.Time
+	dirty bool
+}
+
+type poolKey struct {
+	key   string
+	limit int
+	used  int
+	day   string
+	// The API answered "Request limit reached!" today
+	exhausted bool
+	// The API answered "Invalid API key!", until the end of the run
+	invalid bool
+}
+
+// Usage of one key of a KeyPool
+type KeyUsage struct {
+	// Masked key, only the first and last 2 characters are shown
+	Key string `json:"key"`
+	// UTC day of the counts
+	Day  string `json:"day"`
+	Used int    `json:"used"`
+	// Daily limit, 0 for none
+	Limit int `json:"limit"`
+	// Requests left today, -1 without limit
+	Remaining int  `json:"remaining"`
+	Exhausted bool `json:"exhausted"`
+	Invalid   bool `json:"invalid"`
+}
+
+// Saved usage of a key
+type savedUsage struct {
+	Day       string `json:"day"`
+	Used      int    `json:"used"`
+	Exhausted bool   `json:"exhausted,omitempty"`
+}
+
+// Create a pool of the keys with the same daily limit, 0 for no limit.
+// Duplicated and empty keys are ignored.
+func NewKeyPool(limit int, keys ...string) *KeyPool {
+	p := &KeyPool{now: time.Now}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		p.keys = append(p.keys, &poolKey{key: key, limit: limit})
+	}
+	return p
+}
+
+// Create a pool saving its usage to path, the usage already in the file is
+// read. A missing file is not an error.
+func LoadKeyPool(path string, limit int, keys ...string) (*KeyPool, error) {
+	p := NewKeyPool(limit, keys...)
+	p.Path = path
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	saved := map[string]savedUsage{}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, k := range p.keys {
+		if u, ok := saved[hashKey(k.key)]; ok {
+			k.day, k.used, k.exhausted = u.Day, u.Used, u.Exhausted
+		}
+	}
+	return p, nil
+}
+
+// Change the daily limit of one key of the pool
+func (p *KeyPool) SetLimit(key string, limit int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, k := range p.keys {
+		if k.key == key {
+			k.limit = limit
+		}
+	}
+}
+
+// Return the usage of every key, in the order of the pool
+func (p *KeyPool) Usage() []KeyUsage {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	today := p.today()
+	list := []KeyUsage{}
+	for _, k := range p.keys {
+		k.reset(today)
+		u := KeyUsage{Key: MaskKey(k.key), Day: k.day, Used: k.used, Limit: k.limit, Remaining: -1,
+			Exhausted: k.exhausted, Invalid: k.invalid}
+		if k.limit > 0 {
+			u.Remaining = maxInt(k.limit-k.used, 0)
+		}
+		list = append(list, u)
+	}
+	return list
+}
+
+// Return the key to use for the next request and count the request. The
+// error wrap ErrRequestLimitReached when every valid key is over its limit,
+// ErrInvalidAPIKey when no key is valid.
+func (p *KeyPool) acquire() (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	today := p.today()
+	invalid := 0
+	for _, k := range p.keys {
+		k.reset(today)
+		if k.invalid {
+			invalid++
+			continue
+		}
+		if k.exhausted || (k.limit > 0 && k.used >= k.limit) {
+			continue
+		}
+		k.used++
+		p.save(k.limit > 0 && k.used >= k.limit)
+		return k.key, nil
+	}
+	if len(p.keys) == 0 || invalid == len(p.keys) {
+		return "", fmt.Errorf("omdb: no valid API key in the pool: %w", ErrInvalidAPIKey)
+	}
+	return "", fmt.Errorf("omdb: every API key reached its daily limit: %w", ErrRequestLimitReached)
+}
+
+// Count one more request sent with the key, a retry of the same request
+func (p *KeyPool) use(key string) {
+	p.update(key, false, func(k *poolKey) { k.used++ })
+}
+
+// Record that the API refused the key for today
+func (p *KeyPool) exhausted(key string) {
+	p.update(key, true, func(k *poolKey) { k.exhausted = true })
+}
+
+// Record that the API does not know the key
+func (p *KeyPool) invalid(key string) {
+	p.update(key, true, func(k *poolKey) { k.invalid = true })
+}
+
+func (p *KeyPool) update(key string, force bool, fn func(k *poolKey)) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	today := p.today()
+	for _, k := range p.keys {
+		if k.key == key {
+			k.reset(today)
+			fn(k)
+		}
+	}
+	p.save(force)
+}
+
+// Write the counts not saved yet to p.Path
+func (p *KeyPool) Save() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.dirty {
+		return nil
+	}
+	return p.write()
+}
+
+func (p *KeyPool) clock() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+	return time.Now()
+}
+
+func (p *KeyPool) today() string {
+	return p.clock().UTC().Format(usageDayLayout)
+}
+
+// Start a new day, the API reset the counts at midnight
+func (k *poolKey) reset(today string) {
+	if k.day != today {
+		k.day, k.used, k.exhausted = today, 0, false
+	}
+}
+
+// Save the usage after a change, the caller hold the lock. Unless force is
+// set the write is skipped when the last one is recent, Save write it later.
+// Errors are ignored, a failed write only cost counts starting from the
+// last saved ones.
+func (p *KeyPool) save(force bool) {
+	p.dirty = true
+	if !force && p.clock().Sub(p.saved) < keyPoolSaveInterval {
+		return
+	}
+	p.write()
+}
+
+// Write the usage to p.Path through a temporary file of its own, the caller
+// hold the lock
+func (p *KeyPool) write() error {
+	if p.Path == "" {
+		p.dirty = false
+		return nil
+	}
+	saved := map[string]savedUsage{}
+	for _, k := range p.keys {
+		saved[hashKey(k.key)] = savedUsage{Day: k.day, Used: k.used, Exhausted: k.exhausted}
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(p.Path), ".keys-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), p.Path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	p.saved, p.dirty = p.clock(), false
+	return nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Hide an API key for logs and monitoring, "abcd1234" become "ab****34"
+func MaskKey(key string) string {
+	if len(key) <= 4 {
+		return "****"
+	}
+	return key[:2] + "****" + key[len(key)-2:]
+}
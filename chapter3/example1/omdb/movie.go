@@ -0,0 +1,307 @@
+package omdb
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Value omdbapi.com use for missing fields
+const NotAvailable = "N/A"
+
+// Layout of the Released and DVD dates, example: "05 May 2017"
+const DateLayout = "02 Jan 2006"
+
+// Layout of the release dates in a season episode list, example: "2011-04-17"
+const EpisodeDateLayout = "2006-01-02"
+
+// The structure of the return JSON from omdbapi.com.
+// The string values of the API are parsed into typed fields and "N/A"
+// become the zero value. MarshalJSON write back the omdbapi.com format.
+type MovieInfo struct {
+	Title        string
+	Year         string
+	Rated        string
+	Released     time.Time
+	Runtime      time.Duration
+	Genre        []string
+	Director     []string
+	Writer       []string
+	Actors       []string
+	Plot         string
+	Language     []string
+	Country      []string
+	Awards       string
+	Poster       string
+	Ratings      []Rating
+	Metascore    int
+	ImdbRating   float64
+	ImdbVotes    int
+	ImdbID       string
+	Type         string
+	DVD          time.Time
+	BoxOffice    int64
+	Production   string
+	Website      string
+	TotalSeasons int
+	// Only set for episodes
+	Season   int
+	Episode  int
+	SeriesID string
+}
+
+// Rating from one source, example: {"Rotten Tomatoes", "85%"}
+type Rating struct {
+	Source string `json:"Source"`
+	Value  string `json:"Value"`
+}
+
+// First year of Year, 0 when unknown. Series use ranges like "2011–2019".
+func (mi *MovieInfo) StartYear() int {
+	year, _ := strconv.Atoi(leadingDigits(mi.Year))
+	return year
+}
+
+// The payload as sent by omdbapi.com, every value is a string
+type movieJSON struct {
+	Title        string   `json:"Title"`
+	Year         string   `json:"Year"`
+	Rated        string   `json:"Rated"`
+	Released     string   `json:"Released"`
+	Runtime      string   `json:"Runtime"`
+	Genre        string   `json:"Genre"`
+	Director     string   `json:"Director"`
+	Writer       string   `json:"Writer"`
+	Actors       string   `json:"Actors"`
+	Plot         string   `json:"Plot"`
+	Language     string   `json:"Language"`
+	Country      string   `json:"Country"`
+	Awards       string   `json:"Awards"`
+	Poster       string   `json:"Poster"`
+	Ratings      []Rating `json:"Ratings"`
+	Metascore    string   `json:"Metascore"`
+	ImdbRating   string   `json:"imdbRating"`
+	ImdbVotes    string   `json:"imdbVotes"`
+	ImdbID       string   `json:"imdbID"`
+	Type         string   `json:"Type"`
+	DVD          string   `json:"DVD,omitempty"`
+	BoxOffice    string   `json:"BoxOffice,omitempty"`
+	Production   string   `json:"Production,omitempty"`
+	Website      string   `json:"Website,omitempty"`
+	TotalSeasons string   `json:"totalSeasons,omitempty"`
+	Season       string   `json:"Season,omitempty"`
+	Episode      string   `json:"Episode,omitempty"`
+	SeriesID     string   `json:"seriesID,omitempty"`
+}
+
+func (mi *MovieInfo) UnmarshalJSON(data []byte) error {
+	raw := movieJSON{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*mi = raw.movieInfo()
+	return nil
+}
+
+func (mi MovieInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newMovieJSON(&mi))
+}
+
+func (raw *movieJSON) movieInfo() MovieInfo {
+	return MovieInfo{
+		Title:        text(raw.Title),
+		Year:         text(raw.Year),
+		Rated:        text(raw.Rated),
+		Released:     parseDate(raw.Released),
+		Runtime:      parseRuntime(raw.Runtime),
+		Genre:        parseList(raw.Genre),
+		Director:     parseList(raw.Director),
+		Writer:       parseList(raw.Writer),
+		Actors:       parseList(raw.Actors),
+		Plot:         text(raw.Plot),
+		Language:     parseList(raw.Language),
+		Country:      parseList(raw.Country),
+		Awards:       text(raw.Awards),
+		Poster:       text(raw.Poster),
+		Ratings:      raw.Ratings,
+		Metascore:    int(parseNumber(raw.Metascore)),
+		ImdbRating:   parseFloat(raw.ImdbRating),
+		ImdbVotes:    int(parseNumber(raw.ImdbVotes)),
+		ImdbID:       text(raw.ImdbID),
+		Type:         text(raw.Type),
+		DVD:          parseDate(raw.DVD),
+		BoxOffice:    parseNumber(raw.BoxOffice),
+		Production:   text(raw.Production),
+		Website:      text(raw.Website),
+		TotalSeasons: int(parseNumber(raw.TotalSeasons)),
+		Season:       int(parseNumber(raw.Season)),
+		Episode:      int(parseNumber(raw.Episode)),
+		SeriesID:     text(raw.SeriesID),
+	}
+}
+
+func newMovieJSON(mi *MovieInfo) movieJSON {
+	raw := movieJSON{
+		Title:      mi.Title,
+		Year:       orNA(mi.Year),
+		Rated:      orNA(mi.Rated),
+		Released:   formatDate(mi.Released),
+		Runtime:    NotAvailable,
+		Genre:      formatList(mi.Genre),
+		Director:   formatList(mi.Director),
+		Writer:     formatList(mi.Writer),
+		Actors:     formatList(mi.Actors),
+		Plot:       orNA(mi.Plot),
+		Language:   formatList(mi.Language),
+		Country:    formatList(mi.Country),
+		Awards:     orNA(mi.Awards),
+		Poster:     orNA(mi.Poster),
+		Ratings:    mi.Ratings,
+		Metascore:  NotAvailable,
+		ImdbRating: NotAvailable,
+		ImdbVotes:  NotAvailable,
+		ImdbID:     mi.ImdbID,
+		Type:       mi.Type,
+		Production: mi.Production,
+		Website:    mi.Website,
+		SeriesID:   mi.SeriesID,
+	}
+	if raw.Ratings == nil {
+		raw.Ratings = []Rating{}
+	}
+	if mi.Runtime > 0 {
+		raw.Runtime = strconv.Itoa(int(mi.Runtime/time.Minute)) + " min"
+	}
+	if mi.Metascore > 0 {
+		raw.Metascore = strconv.Itoa(mi.Metascore)
+	}
+	if mi.ImdbRating > 0 {
+		raw.ImdbRating = strconv.FormatFloat(mi.ImdbRating, 'f', 1, 64)
+	}
+	if mi.ImdbVotes > 0 {
+		raw.ImdbVotes = formatThousands(int64(mi.ImdbVotes))
+	}
+	if !mi.DVD.IsZero() {
+		raw.DVD = formatDate(mi.DVD)
+	}
+	if mi.BoxOffice > 0 {
+		raw.BoxOffice = "$" + formatThousands(mi.BoxOffice)
+	}
+	if mi.TotalSeasons > 0 {
+		raw.TotalSeasons = strconv.Itoa(mi.TotalSeasons)
+	}
+	if mi.Season > 0 {
+		raw.Season = strconv.Itoa(mi.Season)
+	}
+	if mi.Episode > 0 {
+		raw.Episode = strconv.Itoa(mi.Episode)
+	}
+	return raw
+}
+
+// Return "" for "N/A"
+func text(s string) string {
+	s = strings.TrimSpace(s)
+	if s == NotAvailable {
+		return ""
+	}
+	return s
+}
+
+func orNA(s string) string {
+	if s == "" {
+		return NotAvailable
+	}
+	return s
+}
+
+// Split a comma separated value, example: "Action, Adventure, Comedy"
+func parseList(s string) []string {
+	s = text(s)
+	if s == "" {
+		return nil
+	}
+	list := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func formatList(list []string) string {
+	return orNA(strings.Join(list, ", "))
+}
+
+func parseDate(s string) time.Time {
+	s = text(s)
+	for _, layout := range []string{DateLayout, EpisodeDateLayout} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return NotAvailable
+	}
+	return t.Format(DateLayout)
+}
+
+// Parse runtimes like "136 min" or "1 h 30 min"
+func parseRuntime(s string) time.Duration {
+	var total time.Duration
+	var value int
+	for _, field := range strings.Fields(text(s)) {
+		if n, err := strconv.Atoi(field); err == nil {
+			value = n
+			continue
+		}
+		switch strings.ToLower(field) {
+		case "h", "hr", "hrs", "hour", "hours":
+			total += time.Duration(value) * time.Hour
+		case "min", "mins", "minute", "minutes":
+			total += time.Duration(value) * time.Minute
+		}
+		value = 0
+	}
+	return total
+}
+
+// Parse numbers like "$292,576,195", "659,355" or "74", ignoring any other character
+func parseNumber(s string) int64 {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, text(s))
+	n, _ := strconv.ParseInt(digits, 10, 64)
+	return n
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(text(s), 64)
+	return f
+}
+
+func formatThousands(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+func leadingDigits(s string) string {
+	for i, r := range s {
+		if r < '0' || r > '9' {
+			return s[:i]
+		}
+	}
+	return s
+}
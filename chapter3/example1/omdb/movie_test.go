@@ -0,0 +1,68 @@
+package omdb
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const guardiansJSON = `{"Title":"Guardians of the Galaxy Vol. 2","Year":"2017","Rated":"PG-13","Released":"05 May 2017","Runtime":"136 min","Genre":"Action, Adventure, Comedy","Director":"James Gunn","Writer":"James Gunn, Dan Abnett","Actors":"Chris Pratt, Zoe Saldana, Dave Bautista","Plot":"The Guardians struggle to keep together as a team.","Language":"English","Country":"United States","Awards":"Nominated for 1 Oscar","Poster":"https://example.com/poster.jpg","Ratings":[{"Source":"Internet Movie Database","Value":"7.6/10"}],"Metascore":"67","imdbRating":"7.6","imdbVotes":"659,355","imdbID":"tt3896198","Type":"movie","DVD":"22 Aug 2017","BoxOffice":"$389,813,101","Production":"N/A","Website":"N/A","Response":"True"}`
+
+func TestMovieInfoUnmarshal(t *testing.T) {
+	mi := MovieInfo{}
+	if err := json.Unmarshal([]byte(guardiansJSON), &mi); err != nil {
+		t.Fatal(err)
+	}
+	if !mi.Released.Equal(time.Date(2017, time.May, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Released = %v", mi.Released)
+	}
+	if mi.Runtime != 136*time.Minute {
+		t.Errorf("Runtime = %v", mi.Runtime)
+	}
+	if !reflect.DeepEqual(mi.Genre, []string{"Action", "Adventure", "Comedy"}) {
+		t.Errorf("Genre = %q", mi.Genre)
+	}
+	if mi.ImdbRating != 7.6 || mi.ImdbVotes != 659355 || mi.Metascore != 67 {
+		t.Errorf("ratings = %v %v %v", mi.ImdbRating, mi.ImdbVotes, mi.Metascore)
+	}
+	if mi.BoxOffice != 389813101 {
+		t.Errorf("BoxOffice = %v", mi.BoxOffice)
+	}
+	if mi.Production != "" || mi.Website != "" {
+		t.Errorf("N/A not turned into zero value: %q %q", mi.Production, mi.Website)
+	}
+	if mi.StartYear() != 2017 {
+		t.Errorf("StartYear = %v", mi.StartYear())
+	}
+}
+
+func TestMovieInfoRoundTrip(t *testing.T) {
+	mi := MovieInfo{}
+	if err := json.Unmarshal([]byte(guardiansJSON), &mi); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(mi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again := MovieInfo{}
+	if err := json.Unmarshal(data, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(mi, again) {
+		t.Errorf("round trip changed the movie:\n%+v\n%+v", mi, again)
+	}
+}
+
+func TestParseRuntime(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"136 min":    136 * time.Minute,
+		"1 h 30 min": 90 * time.Minute,
+		"N/A":        0,
+	} {
+		if got := parseRuntime(s); got != want {
+			t.Errorf("parseRuntime(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
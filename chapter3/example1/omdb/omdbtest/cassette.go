@@ -0,0 +1,245 @@
+package omdbtest
+
+/*
+A Cassette is an http.RoundTripper recording the exchanges with the real
+omdbapi.com to a file and replaying them later without network access:
+
+	// Record once, with a real API key
+	rec := omdbtest.NewRecorder("testdata/cassettes/inception.json", nil)
+	client := omdb.NewClient("", &http.Client{Transport: rec}, "", "")
+	...
+	err := rec.Save()
+
+	// Replay in the tests
+	cassette, err := omdbtest.LoadCassette("testdata/cassettes/inception.json")
+	...
+	client := omdb.NewClient("", &http.Client{Transport: cassette}, "any-key", "")
+
+The API key is never written to the file. Requests are matched on the method
+and the query without the API key, with the parameter names sorted and the
+values trimmed and lower cased, so "t=Inception" replay "t=inception ".
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Value replacing the API key in the recorded URLs
+const Redacted = "REDACTED"
+
+// Error of a request without a recorded interaction in strict mode,
+// use errors.Is to test for it
+var ErrUnmatched = errors.New("omdbtest: no recorded interaction")
+
+// One recorded request and its answer
+type Interaction struct {
+	Method string `json:"method"`
+	// URL of the request with the API key redacted
+	URL string `json:"url"`
+	// Normalized query, used to match the requests
+	Query  string      `json:"query"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// Record or replay the exchanges of a client, see the package documentation
+type Cassette struct {
+	// File holding the interactions
+	Path string
+	// Transport of the requests to record, http.DefaultTransport when nil
+	Transport http.RoundTripper
+	// When replaying, answer the requests without a recorded interaction with
+	// an error instead of sending them with Transport and recording them
+	Strict bool
+
+	mutex        sync.Mutex
+	recording    bool
+	interactions []Interaction
+	used         []bool
+	unmatched    []string
+}
+
+// Create a cassette recording every request sent through it, Save write the file
+func NewRecorder(path string, transport http.RoundTripper) *Cassette {
+	return &Cassette{Path: path, Transport: transport, recording: true}
+}
+
+// Load a cassette to replay, in strict mode
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cassette{Path: path, Strict: true}
+	if err := json.Unmarshal(data, &c.interactions); err != nil {
+		return nil, fmt.Errorf("omdbtest: %s: %w", path, err)
+	}
+	c.used = make([]bool, len(c.interactions))
+	return c, nil
+}
+
+// Write the interactions to the file, creating its directory if needed
+func (c *Cassette) Save() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	data, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.Path, append(data, '\n'), 0644)
+}
+
+// Return the recorded interactions
+func (c *Cassette) Interactions() []Interaction {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]Interaction(nil), c.interactions...)
+}
+
+// Return the requests that had no recorded interaction, as "METHOD query"
+func (c *Cassette) Unmatched() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]string(nil), c.unmatched...)
+}
+
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	query := NormalizeQuery(req.URL.Query())
+	c.mutex.Lock()
+	if !c.recording {
+		if it, ok := c.match(req.Method, query); ok {
+			c.mutex.Unlock()
+			return it.response(req), nil
+		}
+		c.unmatched = append(c.unmatched, req.Method+" "+query)
+		if c.Strict {
+			c.mutex.Unlock()
+			return nil, fmt.Errorf("%w for %s %s in %s", ErrUnmatched, req.Method, query, c.Path)
+		}
+	}
+	c.mutex.Unlock()
+	return c.record(req, query)
+}
+
+// Find the first unused interaction for the request, once they have all
+// been used the last one is replayed again, for the retries and the caches
+// sending the same request several times. The caller hold the mutex.
+func (c *Cassette) match(method, query string) (Interaction, bool) {
+	last := -1
+	for i, it := range c.interactions {
+		if it.Method != method || it.Query != query {
+			continue
+		}
+		if !c.used[i] {
+			c.used[i] = true
+			return it, true
+		}
+		last = i
+	}
+	if last < 0 {
+		return Interaction{}, false
+	}
+	return c.interactions[last], true
+}
+
+func (c *Cassette) record(req *http.Request, query string) (*http.Response, error) {
+	transport := c.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	it := Interaction{
+		Method: req.Method,
+		URL:    redactURL(req.URL),
+		Query:  query,
+		Status: resp.StatusCode,
+		Header: http.Header{},
+		Body:   string(body),
+	}
+	if key := req.URL.Query().Get("apikey"); key != "" {
+		it.Body = strings.Replace(it.Body, key, Redacted, -1)
+	}
+	for _, name := range []string{"Content-Type", "Retry-After"} {
+		if value := resp.Header.Get(name); value != "" {
+			it.Header.Set(name, value)
+		}
+	}
+	c.mutex.Lock()
+	c.interactions = append(c.interactions, it)
+	c.used = append(c.used, true)
+	c.mutex.Unlock()
+	return resp, nil
+}
+
+func (it Interaction) response(req *http.Request) *http.Response {
+	header := http.Header{}
+	for name, values := range it.Header {
+		header[name] = append([]string(nil), values...)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", it.Status, http.StatusText(it.Status)),
+		StatusCode:    it.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(it.Body)),
+		ContentLength: int64(len(it.Body)),
+		Request:       req,
+	}
+}
+
+// Return the query without the API key, the names sorted and the values
+// trimmed, lower cased and with the inner spaces collapsed
+func NormalizeQuery(query url.Values) string {
+	names := []string{}
+	for name := range query {
+		if name != "apikey" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	parts := []string{}
+	for _, name := range names {
+		for _, value := range query[name] {
+			value = strings.Join(strings.Fields(strings.ToLower(value)), " ")
+			parts = append(parts, url.QueryEscape(name)+"="+url.QueryEscape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func redactURL(u *url.URL) string {
+	redacted := *u
+	query := u.Query()
+	if query.Get("apikey") != "" {
+		query.Set("apikey", Redacted)
+		redacted.RawQuery = query.Encode()
+	}
+	return redacted.String()
+}
@@ -0,0 +1,138 @@
+package omdbtest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"sort"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Write the answers in JSON or, for r=xml, in the XML format of omdbapi.com
+// where the values are attributes of <movie> or <result> elements.
+type responder struct {
+	w   http.ResponseWriter
+	xml bool
+}
+
+func (r *responder) fail(status int, message string) {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if message == "" {
+		r.w.WriteHeader(status)
+		return
+	}
+	if r.xml {
+		r.writeXML(status, xmlElement{Name: "root", Attrs: [][2]string{{"response", "False"}}, Children: []xmlElement{{Name: "error", Text: message}}})
+		return
+	}
+	r.writeJSON(status, document{"Response": "False", "Error": message})
+}
+
+func (r *responder) movie(doc document) {
+	if r.xml {
+		r.writeXML(http.StatusOK, xmlElement{Name: "root", Attrs: [][2]string{{"response", "True"}}, Children: []xmlElement{attrElement("movie", doc)}})
+		return
+	}
+	out := document{"Response": "True"}
+	for key, value := range doc {
+		out[key] = value
+	}
+	r.writeJSON(http.StatusOK, out)
+}
+
+func (r *responder) search(results []document, total int) {
+	if r.xml {
+		root := xmlElement{Name: "root", Attrs: [][2]string{{"totalResults", strconv.Itoa(total)}, {"response", "True"}}}
+		for _, result := range results {
+			root.Children = append(root.Children, attrElement("result", result))
+		}
+		r.writeXML(http.StatusOK, root)
+		return
+	}
+	r.writeJSON(http.StatusOK, document{"Search": results, "totalResults": strconv.Itoa(total), "Response": "True"})
+}
+
+func (r *responder) season(header document, episodes []document) {
+	if r.xml {
+		root := attrElement("root", header)
+		root.Attrs = append(root.Attrs, [2]string{"response", "True"})
+		for _, ep := range episodes {
+			root.Children = append(root.Children, attrElement("result", ep))
+		}
+		r.writeXML(http.StatusOK, root)
+		return
+	}
+	out := document{"Episodes": episodes, "Response": "True"}
+	for key, value := range header {
+		out[key] = value
+	}
+	r.writeJSON(http.StatusOK, out)
+}
+
+func (r *responder) writeJSON(status int, v interface{}) {
+	r.w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	r.w.WriteHeader(status)
+	json.NewEncoder(r.w).Encode(v)
+}
+
+func (r *responder) writeXML(status int, root xmlElement) {
+	r.w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	r.w.WriteHeader(status)
+	r.w.Write([]byte(xml.Header))
+	xml.NewEncoder(r.w).Encode(root)
+}
+
+// Element with ordered attributes, encoding/xml need static types otherwise
+type xmlElement struct {
+	Name     string
+	Attrs    [][2]string
+	Text     string
+	Children []xmlElement
+}
+
+func (e xmlElement) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Local: e.Name}}
+	for _, attr := range e.Attrs {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: attr[0]}, Value: attr[1]})
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	if e.Text != "" {
+		if err := enc.EncodeToken(xml.CharData(e.Text)); err != nil {
+			return err
+		}
+	}
+	for _, child := range e.Children {
+		if err := enc.Encode(child); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// Element with the string values of the document as attributes. The names
+// start with a lower case letter like omdbapi.com does: Title become title.
+func attrElement(name string, doc document) xmlElement {
+	keys := []string{}
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	e := xmlElement{Name: name}
+	for _, key := range keys {
+		if value, ok := doc[key].(string); ok {
+			e.Attrs = append(e.Attrs, [2]string{lowerFirst(key), value})
+		}
+	}
+	return e
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
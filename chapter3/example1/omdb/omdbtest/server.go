@@ -0,0 +1,411 @@
+// Package omdbtest provide a fake omdbapi.com server for tests and local
+// development, backed by a directory of JSON documents, and a Cassette
+// transport recording and replaying the exchanges with the real API.
+package omdbtest
+
+/*
+Each *.json file of the fixture directory hold one OMDb document or an array
+of documents, in the format of the i= answers of omdbapi.com. Episodes carry
+the "seriesID", "Season" and "Episode" fields, the season lists are built from
+them. An optional "FullPlot" field is returned as "Plot" for plot=full.
+
+	srv, err := omdbtest.NewServer(omdbtest.FixtureDir())
+	...
+	defer srv.Close()
+	client := omdb.NewClient(srv.URL, srv.Client(), omdbtest.APIKey, "")
+
+For local development the Handler can be served directly:
+
+	h, _ := omdbtest.NewHandler(dir)
+	http.ListenAndServe(":8081", h)
+*/
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// API key accepted by a new server
+const APIKey = "test-key"
+
+// Error messages sent by omdbapi.com
+const (
+	MsgNoAPIKey       = "No API key provided."
+	MsgInvalidAPIKey  = "Invalid API key!"
+	MsgLimitReached   = "Request limit reached!"
+	MsgMovieNotFound  = "Movie not found!"
+	MsgIncorrectID    = "Incorrect IMDb ID."
+	MsgSeriesNotFound = "Series or episode not found!"
+	MsgTooManyResults = "Too many results."
+)
+
+// Return the directory of the fixtures shipped with this package
+func FixtureDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "testdata")
+}
+
+// A document of the fixture directory
+type document map[string]interface{}
+
+func (d document) get(key string) string {
+	s, _ := d[key].(string)
+	return s
+}
+
+// Answer the OMDb query protocol from the fixtures.
+// The settings can be changed while the handler is serving.
+type Handler struct {
+	mutex    sync.Mutex
+	docs     []document
+	keys     map[string]bool
+	latency  time.Duration
+	quota    int
+	usage    map[string]int
+	failures []failure
+	requests int
+}
+
+type failure struct {
+	status  int
+	message string
+}
+
+// Create a handler serving the fixtures of dir, an empty dir mean no fixture.
+// Only APIKey is accepted until SetAPIKeys is called.
+func NewHandler(dir string) (*Handler, error) {
+	h := &Handler{keys: map[string]bool{APIKey: true}, usage: map[string]int{}}
+	if dir == "" {
+		return h, nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := h.AddJSON(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return h, nil
+}
+
+// Add one document or an array of documents
+func (h *Handler) AddJSON(data []byte) error {
+	docs := []document{}
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		if err := json.Unmarshal(data, &docs); err != nil {
+			return err
+		}
+	} else {
+		doc := document{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		docs = append(docs, doc)
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for _, doc := range docs {
+		if doc.get("imdbID") == "" {
+			return fmt.Errorf("document without imdbID: %v", doc["Title"])
+		}
+		h.docs = append(h.docs, doc)
+	}
+	return nil
+}
+
+// Replace the accepted API keys, no key at all mean any key is accepted
+func (h *Handler) SetAPIKeys(keys ...string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.keys = map[string]bool{}
+	for _, key := range keys {
+		h.keys[key] = true
+	}
+}
+
+// Delay every answer, the delay end early if the request is canceled
+func (h *Handler) SetLatency(latency time.Duration) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.latency = latency
+}
+
+// Number of requests accepted for each key before "Request limit reached!",
+// zero mean no limit. The usage is reset.
+func (h *Handler) SetQuota(quota int) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.quota = quota
+	h.usage = map[string]int{}
+}
+
+// Answer the next n requests with the HTTP status and the error message.
+// A status of 200 with a message simulate a "Response":"False" payload,
+// an empty message send the status without a body.
+func (h *Handler) FailNext(n, status int, message string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for i := 0; i < n; i++ {
+		h.failures = append(h.failures, failure{status, message})
+	}
+}
+
+// Number of requests received
+func (h *Handler) Requests() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.requests
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	h.mutex.Lock()
+	h.requests++
+	latency := h.latency
+	h.mutex.Unlock()
+	if latency > 0 && !wait(r.Context(), latency) {
+		return
+	}
+	resp := &responder{w: w, xml: q.Get("r") == "xml"}
+
+	h.mutex.Lock()
+	status, message := h.admit(q.Get("apikey"))
+	h.mutex.Unlock()
+	if message != "" || status != 0 {
+		resp.fail(status, message)
+		return
+	}
+
+	switch {
+	case q.Get("i") != "" && q.Get("Season") != "" && q.Get("Episode") == "":
+		h.season(resp, q.Get("i"), q.Get("Season"))
+	case q.Get("i") != "" && q.Get("Season") != "":
+		h.episode(resp, q.Get("i"), q.Get("Season"), q.Get("Episode"), q.Get("plot"))
+	case q.Get("i") != "":
+		h.byID(resp, q.Get("i"), q.Get("plot"))
+	case q.Get("t") != "":
+		h.byTitle(resp, q.Get("t"), q.Get("y"), q.Get("type"), q.Get("plot"))
+	case q.Get("s") != "":
+		h.search(resp, q.Get("s"), q.Get("y"), q.Get("type"), q.Get("page"))
+	default:
+		resp.fail(http.StatusOK, MsgIncorrectID)
+	}
+}
+
+// Check the key, the quota and the simulated failures
+func (h *Handler) admit(key string) (int, string) {
+	if len(h.failures) > 0 {
+		f := h.failures[0]
+		h.failures = h.failures[1:]
+		return f.status, f.message
+	}
+	if key == "" {
+		return http.StatusUnauthorized, MsgNoAPIKey
+	}
+	if len(h.keys) > 0 && !h.keys[key] {
+		return http.StatusUnauthorized, MsgInvalidAPIKey
+	}
+	if h.quota > 0 && h.usage[key] >= h.quota {
+		return http.StatusUnauthorized, MsgLimitReached
+	}
+	h.usage[key]++
+	return 0, ""
+}
+
+func wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Copy of the document with the plot chosen by the plot parameter
+func withPlot(doc document, plot string) document {
+	out := document{}
+	for key, value := range doc {
+		out[key] = value
+	}
+	delete(out, "FullPlot")
+	if full := doc.get("FullPlot"); plot == "full" && full != "" {
+		out["Plot"] = full
+	}
+	return out
+}
+
+func (h *Handler) find(match func(document) bool) []document {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	found := []document{}
+	for _, doc := range h.docs {
+		if match(doc) {
+			found = append(found, doc)
+		}
+	}
+	return found
+}
+
+func (h *Handler) byID(resp *responder, id, plot string) {
+	docs := h.find(func(doc document) bool { return strings.EqualFold(doc.get("imdbID"), id) })
+	if len(docs) == 0 {
+		resp.fail(http.StatusOK, MsgIncorrectID)
+		return
+	}
+	resp.movie(withPlot(docs[0], plot))
+}
+
+func (h *Handler) byTitle(resp *responder, title, year, kind, plot string) {
+	docs := h.find(func(doc document) bool {
+		return strings.EqualFold(doc.get("Title"), strings.TrimSpace(title)) && filter(doc, year, kind)
+	})
+	if len(docs) == 0 {
+		resp.fail(http.StatusOK, MsgMovieNotFound)
+		return
+	}
+	resp.movie(withPlot(docs[0], plot))
+}
+
+func filter(doc document, year, kind string) bool {
+	if kind != "" && !strings.EqualFold(doc.get("Type"), kind) {
+		return false
+	}
+	return year == "" || strings.HasPrefix(doc.get("Year"), year)
+}
+
+func (h *Handler) search(resp *responder, query, year, kind, pageParm string) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if len(query) < 3 {
+		resp.fail(http.StatusOK, MsgTooManyResults)
+		return
+	}
+	page := 1
+	if pageParm != "" {
+		n, err := strconv.Atoi(pageParm)
+		if err != nil || n < 1 || n > 100 {
+			resp.fail(http.StatusOK, "The offset specified in a OFFSET clause may not be negative.")
+			return
+		}
+		page = n
+	}
+	docs := h.find(func(doc document) bool {
+		return doc.get("Type") != "episode" &&
+			strings.Contains(strings.ToLower(doc.get("Title")), query) && filter(doc, year, kind)
+	})
+	start := (page - 1) * 10
+	if start >= len(docs) {
+		resp.fail(http.StatusOK, MsgMovieNotFound)
+		return
+	}
+	end := start + 10
+	if end > len(docs) {
+		end = len(docs)
+	}
+	results := []document{}
+	for _, doc := range docs[start:end] {
+		results = append(results, document{
+			"Title":  doc.get("Title"),
+			"Year":   doc.get("Year"),
+			"imdbID": doc.get("imdbID"),
+			"Type":   doc.get("Type"),
+			"Poster": doc.get("Poster"),
+		})
+	}
+	resp.search(results, len(docs))
+}
+
+func (h *Handler) episodes(id, season string) []document {
+	docs := h.find(func(doc document) bool {
+		return strings.EqualFold(doc.get("seriesID"), id) && doc.get("Season") == season
+	})
+	sort.Slice(docs, func(i, j int) bool {
+		a, _ := strconv.Atoi(docs[i].get("Episode"))
+		b, _ := strconv.Atoi(docs[j].get("Episode"))
+		return a < b
+	})
+	return docs
+}
+
+func (h *Handler) season(resp *responder, id, season string) {
+	series := h.find(func(doc document) bool {
+		return strings.EqualFold(doc.get("imdbID"), id) && doc.get("Type") == "series"
+	})
+	episodes := h.episodes(id, season)
+	if len(series) == 0 || len(episodes) == 0 {
+		resp.fail(http.StatusOK, MsgSeriesNotFound)
+		return
+	}
+	list := []document{}
+	for _, ep := range episodes {
+		released := ep.get("Released")
+		if t, err := time.Parse("02 Jan 2006", released); err == nil {
+			released = t.Format("2006-01-02")
+		}
+		list = append(list, document{
+			"Title":      ep.get("Title"),
+			"Released":   released,
+			"Episode":    ep.get("Episode"),
+			"imdbRating": ep.get("imdbRating"),
+			"imdbID":     ep.get("imdbID"),
+		})
+	}
+	resp.season(document{
+		"Title":        series[0].get("Title"),
+		"Season":       season,
+		"totalSeasons": series[0].get("totalSeasons"),
+	}, list)
+}
+
+func (h *Handler) episode(resp *responder, id, season, episode, plot string) {
+	for _, ep := range h.episodes(id, season) {
+		if ep.get("Episode") == episode {
+			resp.movie(withPlot(ep, plot))
+			return
+		}
+	}
+	resp.fail(http.StatusOK, MsgSeriesNotFound)
+}
+
+// Fake omdbapi.com started on a local port, see NewHandler for the settings
+type Server struct {
+	*httptest.Server
+	*Handler
+}
+
+// Start a server serving the fixtures of dir
+func NewServer(dir string) (*Server, error) {
+	h, err := NewHandler(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{Server: httptest.NewServer(h), Handler: h}, nil
+}
+
+// Like NewServer but panic if the fixtures can't be loaded
+func MustNewServer(dir string) *Server {
+	srv, err := NewServer(dir)
+	if err != nil {
+		panic("omdbtest: " + err.Error())
+	}
+	return srv
+}
@@ -0,0 +1,185 @@
+package omdbtest_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb/omdbtest"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newClient(t *testing.T) (*omdbtest.Server, *omdb.Client) {
+	srv, err := omdbtest.NewServer(omdbtest.FixtureDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, omdb.NewClient(srv.URL, srv.Client(), omdbtest.APIKey, "")
+}
+
+func TestLookups(t *testing.T) {
+	srv, c := newClient(t)
+	defer srv.Close()
+	ctx := context.Background()
+
+	mi, err := c.SearchById(ctx, "tt3896198")
+	if err != nil || mi.Title != "Guardians of the Galaxy Vol. 2" || mi.Runtime != 136*time.Minute {
+		t.Errorf("SearchById: %+v %v", mi, err)
+	}
+	mi, err = c.SearchByTitle(ctx, omdb.TitleQuery{Title: "inception", Plot: "full"})
+	if err != nil || mi.ImdbID != "tt1375666" {
+		t.Errorf("SearchByTitle: %+v %v", mi, err)
+	}
+	if _, err := c.SearchByName(ctx, "Game of"); !errors.Is(err, omdb.ErrNotFound) {
+		t.Errorf("SearchByName: got %v, want ErrNotFound", err)
+	}
+	results, err := c.SearchAll(ctx, omdb.SearchQuery{Query: "guardians"})
+	if err != nil || len(results) != 2 {
+		t.Errorf("SearchAll: %+v %v", results, err)
+	}
+	seasons, err := c.GetAllSeasons(ctx, "tt0944947")
+	if err != nil || len(seasons) != 2 || len(seasons[0].Episodes) != 2 {
+		t.Errorf("GetAllSeasons: %+v %v", seasons, err)
+	}
+	ep, err := c.GetEpisode(ctx, "tt0944947", 1, 2)
+	if err != nil || ep.Title != "The Kingsroad" || ep.SeriesID != "tt0944947" {
+		t.Errorf("GetEpisode: %+v %v", ep, err)
+	}
+}
+
+func TestSimulatedErrors(t *testing.T) {
+	srv, c := newClient(t)
+	defer srv.Close()
+	ctx := context.Background()
+
+	srv.SetQuota(1)
+	if _, err := c.SearchById(ctx, "tt3896198"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SearchById(ctx, "tt3896198"); !errors.Is(err, omdb.ErrRequestLimitReached) {
+		t.Errorf("quota: got %v", err)
+	}
+	srv.SetQuota(0)
+
+	c.APIKey = "wrong"
+	if _, err := c.SearchById(ctx, "tt3896198"); !errors.Is(err, omdb.ErrInvalidAPIKey) {
+		t.Errorf("invalid key: got %v", err)
+	}
+	c.APIKey = omdbtest.APIKey
+
+	srv.FailNext(1, http.StatusBadGateway, "")
+	c.Retry = omdb.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+	if _, err := c.SearchById(ctx, "tt3896198"); err != nil {
+		t.Errorf("retry after 502: %v", err)
+	}
+
+	srv.SetLatency(time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.SearchById(ctx, "tt3896198"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("latency: got %v", err)
+	}
+}
+
+func TestCassette(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassettes", "lookups.json")
+	ctx := context.Background()
+
+	srv, err := omdbtest.NewServer(omdbtest.FixtureDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := omdbtest.NewRecorder(path, srv.Client().Transport)
+	c := omdb.NewClient(srv.URL, &http.Client{Transport: rec}, omdbtest.APIKey, "")
+	if _, err := c.SearchByTitle(ctx, omdb.TitleQuery{Title: "Inception"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SearchById(ctx, "tt0000001"); !errors.Is(err, omdb.ErrNotFound) {
+		t.Fatalf("got %v, want ErrNotFound", err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+	data, _ := ioutil.ReadFile(path)
+	if strings.Contains(string(data), omdbtest.APIKey) || !strings.Contains(string(data), omdbtest.Redacted) {
+		t.Errorf("API key not scrubbed:\n%s", data)
+	}
+
+	cassette, err := omdbtest.LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = omdb.NewClient(srv.URL, &http.Client{Transport: cassette}, "another-key", "")
+	c.Retry = omdb.RetryPolicy{MaxAttempts: 1}
+	mi, err := c.SearchByTitle(ctx, omdb.TitleQuery{Title: " inception "})
+	if err != nil || mi.ImdbID != "tt1375666" {
+		t.Errorf("replay: %+v %v", mi, err)
+	}
+	if _, err := c.SearchById(ctx, "tt0000001"); !errors.Is(err, omdb.ErrNotFound) {
+		t.Errorf("replay of an error: got %v, want ErrNotFound", err)
+	}
+	if _, err := c.SearchById(ctx, "tt3896198"); !errors.Is(err, omdbtest.ErrUnmatched) {
+		t.Errorf("strict: got %v, want ErrUnmatched", err)
+	}
+	if unmatched := cassette.Unmatched(); len(unmatched) != 1 || unmatched[0] != "GET i=tt3896198" {
+		t.Errorf("got unmatched %v", unmatched)
+	}
+}
+
+func TestXMLFormat(t *testing.T) {
+	srv, c := newClient(t)
+	defer srv.Close()
+	ctx := context.Background()
+	x := omdb.NewClient(srv.URL, srv.Client(), omdbtest.APIKey, "")
+	x.Format = omdb.FormatXML
+
+	for _, id := range []string{"tt3896198", "tt1480055"} {
+		want, err := c.SearchById(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := x.SearchById(ctx, id)
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		// The XML has no Ratings
+		want.Ratings = nil
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got\n%+v\nwant\n%+v", id, got, want)
+		}
+	}
+	results, err := x.SearchAll(ctx, omdb.SearchQuery{Query: "guardians"})
+	if err != nil || len(results) != 2 || results[0].ImdbID == "" {
+		t.Errorf("SearchAll: %+v %v", results, err)
+	}
+	season, err := x.GetSeason(ctx, "tt0944947", 1)
+	if err != nil || season.TotalSeasons != 2 || len(season.Episodes) != 2 || season.Episodes[1].Title != "The Kingsroad" {
+		t.Errorf("GetSeason: %+v %v", season, err)
+	}
+	if _, err := x.SearchById(ctx, "tt0000001"); !errors.Is(err, omdb.ErrNotFound) {
+		t.Errorf("not found: got %v", err)
+	}
+
+	// A stored XML document decode into the same type
+	mi := &omdb.MovieInfo{}
+	doc := `<?xml version="1.0"?><root response="True"><movie title="Inception" year="2010" runtime="148 min" genre="Action, Sci-Fi" imdbRating="8.8" imdbID="tt1375666" type="movie"/></root>`
+	if err := xml.Unmarshal([]byte(doc), mi); err != nil || mi.Runtime != 148*time.Minute || len(mi.Genre) != 2 || mi.ImdbRating != 8.8 {
+		t.Errorf("xml.Unmarshal: %+v %v", mi, err)
+	}
+	if omdb.DecoderFor("", doc) != omdb.XMLDecoder || omdb.DecoderFor("application/json", doc) != omdb.JSONDecoder {
+		t.Error("DecoderFor: wrong decoder")
+	}
+}
@@ -0,0 +1,83 @@
+package omdb
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Error of Wait when a limiter with a rate of 0 has used its burst
+var ErrLimiterExhausted = errorString("omdb: rate limiter exhausted")
+
+// Token bucket limiting the number of requests sent to the API.
+// The bucket hold up to burst tokens and refill at rate tokens per second,
+// every request take one token. A rate of 0 or less never refill the
+// bucket: only burst requests are allowed.
+type RateLimiter struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// Create a limiter allowing rate requests per second with bursts of burst
+// requests. The bucket start full, with a rate of 0 or less Wait return
+// ErrLimiterExhausted once the burst is used.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Create a limiter spreading n requests evenly over the period,
+// example: NewRateLimiterPer(1000, 24*time.Hour) for a daily budget.
+func NewRateLimiterPer(n int, period time.Duration, burst int) *RateLimiter {
+	return NewRateLimiter(float64(n)/period.Seconds(), burst)
+}
+
+// Block until a token is available or the context is done
+func (rl *RateLimiter) Wait(ctx context.Context) error {
+	delay, ok := rl.reserve()
+	if !ok {
+		return ErrLimiterExhausted
+	}
+	if delay <= 0 {
+		return nil
+	}
+	if err := sleep(ctx, delay); err != nil {
+		rl.cancel()
+		return err
+	}
+	return nil
+}
+
+// Take a token, the bucket can go negative, return how long to wait
+// until the token is really available. Return false without taking a
+// token when the bucket is empty and never refilled.
+func (rl *RateLimiter) reserve() (time.Duration, bool) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	now := time.Now()
+	rl.tokens += now.Sub(rl.last).Seconds() * rl.rate
+	if rl.tokens > rl.burst {
+		rl.tokens = rl.burst
+	}
+	rl.last = now
+	if rl.rate <= 0 && rl.tokens < 1 {
+		return 0, false
+	}
+	rl.tokens--
+	if rl.tokens >= 0 {
+		return 0, true
+	}
+	return time.Duration(-rl.tokens / rl.rate * float64(time.Second)), true
+}
+
+// Give back a token that was reserved but not used
+func (rl *RateLimiter) cancel() {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	rl.tokens++
+}
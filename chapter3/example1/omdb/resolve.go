@@ -0,0 +1,259 @@
+package omdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Default settings of the resolver, see ResolveOptions
+const (
+	DefaultMinConfidence = 0.6
+	DefaultMargin        = 0.05
+	DefaultMaxCandidates = 5
+	// Number of s= searches tried at most when the exact lookup miss
+	maxResolveSearches = 3
+)
+
+// Hints and thresholds of Resolve, zero values use the defaults
+type ResolveOptions struct {
+	// Year of release, candidates released close to it rank higher
+	Year int
+	// movie, series or episode, candidates of another type rank lower
+	Type string
+	Plot string
+	// Minimum score of the best candidate, from 0 to 1
+	MinConfidence float64
+	// The best candidate must beat the second by at least this score
+	Margin float64
+	// Number of candidates kept in a Resolution or an AmbiguousError
+	MaxCandidates int
+}
+
+// A search result with its score, from 0 to 1
+type Candidate struct {
+	SearchResult
+	Score float64
+}
+
+func (c Candidate) String() string {
+	return fmt.Sprintf("%s (%s) %s %.2f", c.Title, c.Year, c.ImdbID, c.Score)
+}
+
+// Movie found by Resolve. Confidence is 1 for an exact title match,
+// otherwise the score of the best candidate.
+type Resolution struct {
+	Movie      *MovieInfo
+	Confidence float64
+	Exact      bool
+	// Best candidates of the search, the first one is the movie. Empty for an exact match.
+	Candidates []Candidate
+}
+
+// Error returned by Resolve when no candidate is clearly the best
+type AmbiguousError struct {
+	Query      string
+	Candidates []Candidate
+}
+
+func (e *AmbiguousError) Error() string {
+	list := make([]string, len(e.Candidates))
+	for i, c := range e.Candidates {
+		list[i] = c.String()
+	}
+	return fmt.Sprintf("omdb: ambiguous title %q, candidates: %s", e.Query, strings.Join(list, "; "))
+}
+
+// Find the movie for a title typed by a user. The exact t= lookup is tried
+// first, then the title or its words are searched with s= and the results
+// are ranked by edit distance of the titles, year proximity and type.
+// Return ErrNotFound when nothing come close and *AmbiguousError when the
+// best candidates are too weak or too close to each other.
+func (c *Client) Resolve(ctx context.Context, title string, opts ResolveOptions) (*Resolution, error) {
+	q := TitleQuery{Title: title, Type: opts.Type, Plot: opts.Plot}
+	if opts.Year > 0 {
+		q.Year = strconv.Itoa(opts.Year)
+	}
+	mi, err := c.SearchByTitle(ctx, q)
+	if err == nil {
+		return &Resolution{Movie: mi, Confidence: 1, Exact: true}, nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	candidates, err := c.candidates(ctx, title, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: no title close to %q", ErrNotFound, title)
+	}
+	max := opts.MaxCandidates
+	if max <= 0 {
+		max = DefaultMaxCandidates
+	}
+	if len(candidates) > max {
+		candidates = candidates[:max]
+	}
+	minConfidence, margin := opts.MinConfidence, opts.Margin
+	if minConfidence <= 0 {
+		minConfidence = DefaultMinConfidence
+	}
+	if margin <= 0 {
+		margin = DefaultMargin
+	}
+	best := candidates[0]
+	if best.Score < minConfidence || (len(candidates) > 1 && best.Score-candidates[1].Score < margin) {
+		return nil, &AmbiguousError{Query: title, Candidates: candidates}
+	}
+
+	if opts.Plot != "" {
+		mi, err = c.SearchByTitle(ctx, TitleQuery{Title: best.Title, Year: best.Year, Type: best.Type, Plot: opts.Plot})
+	} else {
+		mi, err = c.SearchById(ctx, best.ImdbID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Resolution{Movie: mi, Confidence: best.Score, Candidates: candidates}, nil
+}
+
+// Search the title, then its longest words when the title alone find
+// nothing, and return the results sorted by score
+func (c *Client) candidates(ctx context.Context, title string, opts ResolveOptions) ([]Candidate, error) {
+	seen := map[string]bool{}
+	candidates := []Candidate{}
+	for i, query := range searchTerms(title) {
+		if i == maxResolveSearches || (i > 0 && len(candidates) > 0) {
+			break
+		}
+		page, err := c.Search(ctx, SearchQuery{Query: query, Type: opts.Type})
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrTooManyResults) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		for _, result := range page.Results {
+			if !seen[result.ImdbID] {
+				seen[result.ImdbID] = true
+				candidates = append(candidates, Candidate{result, score(title, result, opts)})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	return candidates, nil
+}
+
+// The title followed by its words of 3 letters or more, longest first
+func searchTerms(title string) []string {
+	terms := []string{strings.TrimSpace(title)}
+	words := strings.Fields(normalizeTitle(title))
+	sort.SliceStable(words, func(i, j int) bool {
+		return len([]rune(words[i])) > len([]rune(words[j]))
+	})
+	for _, word := range words {
+		if len([]rune(word)) >= 3 && word != terms[0] {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// Score of a search result for the title typed by the user, from 0 to 1
+func score(title string, result SearchResult, opts ResolveOptions) float64 {
+	s := titleSimilarity(normalizeTitle(title), normalizeTitle(result.Title))
+	if opts.Year > 0 {
+		year, _ := strconv.Atoi(leadingDigits(result.Year))
+		d := year - opts.Year
+		if d < 0 {
+			d = -d
+		}
+		// 0.9 one year off, 0.5 from five years
+		if d > 5 {
+			d = 5
+		}
+		s *= 1 - 0.1*float64(d)
+	}
+	if opts.Type != "" && !strings.EqualFold(opts.Type, result.Type) {
+		s *= 0.7
+	}
+	return s
+}
+
+// Similarity of two normalized titles from the edit distance. A query that
+// is the start of the title, like "game of" for "game of thrones", score
+// a little less than the full title.
+func titleSimilarity(query, title string) float64 {
+	q, t := []rune(query), []rune(title)
+	if len(q) == 0 || len(t) == 0 {
+		return 0
+	}
+	s := 1 - float64(levenshtein(q, t))/float64(maxInt(len(q), len(t)))
+	if len(t) > len(q) {
+		prefix := 1 - float64(levenshtein(q, t[:len(q)]))/float64(len(q))
+		if prefix*0.9 > s {
+			s = prefix * 0.9
+		}
+	}
+	return s
+}
+
+// Lower case, punctuation removed and leading article dropped:
+// "The Lord of the Rings: The Two Towers" become "lord of the rings the two towers"
+func normalizeTitle(title string) string {
+	title = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		if r == '\'' {
+			return -1
+		}
+		return ' '
+	}, title)
+	words := strings.Fields(title)
+	if len(words) > 1 && (words[0] == "the" || words[0] == "a" || words[0] == "an") {
+		words = words[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// Edit distance with insertions, deletions and substitutions
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, minInt(cur[j-1]+1, prev[j-1]+cost))
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,106 @@
+package omdb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Default delays used when the RetryPolicy values are zero
+const (
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+	DefaultRetryMaxDelay  = 30 * time.Second
+)
+
+// How failed requests are retried. Transport errors, 5xx and 429 answers
+// are retried with an exponential backoff and full jitter: the delay before
+// attempt n+1 is random between 0 and min(MaxDelay, BaseDelay*2^(n-1)).
+// A Retry-After header longer than that delay is honoured, up to MaxDelay:
+// when the server ask to wait longer the error is returned without retry.
+type RetryPolicy struct {
+	// Total number of attempts, 0 or 1 disable the retries
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var jitter = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// MaxDelay or its default
+func (p RetryPolicy) maxDelay() time.Duration {
+	if p.MaxDelay <= 0 {
+		return DefaultRetryMaxDelay
+	}
+	return p.MaxDelay
+}
+
+// Delay to wait after the failed attempt number attempt (starting at 1)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	base, max := p.BaseDelay, p.maxDelay()
+	if base <= 0 {
+		base = DefaultRetryBaseDelay
+	}
+	delay := max
+	if attempt < 32 {
+		if d := base << uint(attempt-1); d > 0 && d < max {
+			delay = d
+		}
+	}
+	jitter.Lock()
+	defer jitter.Unlock()
+	return time.Duration(jitter.Int63n(int64(delay) + 1))
+}
+
+// Report if the error of an attempt is worth another attempt
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	// *url.Error is a net.Error itself, only the error it wrap tell if the
+	// request failed in transport or could not be sent at all
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if errors.Is(urlErr.Err, io.EOF) {
+			// The server closed the connection before answering
+			return true
+		}
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Parse the Retry-After header, only the number of seconds form is supported
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Wait for the delay or until the context is done
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,138 @@
+package omdb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRetryOn5xx(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"Title":"Guardians of the Galaxy Vol. 2","Response":"True"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, srv.Client(), "secret", "")
+	c.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	if _, err := c.SearchById(context.Background(), "tt3896198"); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 3 {
+		t.Errorf("got %d requests, want 3", requests)
+	}
+}
+
+func TestNoRetryOn4xx(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"Response":"False","Error":"Invalid API key!"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, srv.Client(), "secret", "")
+	c.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	_, err := c.SearchById(context.Background(), "tt3896198")
+	if apiErr, ok := err.(*APIError); !ok || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("got %d requests, want 1", requests)
+	}
+}
+
+func TestRetryAfterAboveMaxDelay(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "86400")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, srv.Client(), "secret", "")
+	c.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+	start := time.Now()
+	_, err := c.SearchById(context.Background(), "tt3896198")
+	if apiErr, ok := err.(*APIError); !ok || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("got %v", err)
+	}
+	if requests != 1 || time.Since(start) > time.Second {
+		t.Errorf("got %d requests in %v, want 1 without waiting", requests, time.Since(start))
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&url.Error{Op: "Get", URL: "http://omdb", Err: refused}, true},
+		{&url.Error{Op: "Get", URL: "http://omdb", Err: io.EOF}, true},
+		{io.ErrUnexpectedEOF, true},
+		{&APIError{StatusCode: http.StatusBadGateway}, true},
+		{&APIError{StatusCode: http.StatusTooManyRequests}, true},
+		{&APIError{StatusCode: http.StatusNotFound}, false},
+		{&url.Error{Op: "Get", URL: "omdb", Err: errors.New("unsupported protocol scheme")}, false},
+		{errors.New("invalid character '<' looking for beginning of value"), false},
+		{ErrNotFound, false},
+	}
+	for _, test := range tests {
+		if got := retryable(context.Background(), test.err); got != test.want {
+			t.Errorf("%v: got %v, want %v", test.err, got, test.want)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if retryable(ctx, io.ErrUnexpectedEOF) {
+		t.Error("retryable after the context is done")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	rl := NewRateLimiter(100, 2)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := rl.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("4 requests with a burst of 2 at 100/s took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewRateLimiter(0.001, 1).Wait(ctx); err != nil {
+		t.Errorf("first token of a full bucket should not wait: %v", err)
+	}
+	empty := NewRateLimiter(0.001, 1)
+	empty.Wait(context.Background())
+	if err := empty.Wait(ctx); err != context.Canceled {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+
+	burstOnly := NewRateLimiter(0, 2)
+	for i := 0; i < 2; i++ {
+		if err := burstOnly.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := burstOnly.Wait(context.Background()); err != ErrLimiterExhausted {
+		t.Errorf("got %v, want ErrLimiterExhausted", err)
+	}
+}
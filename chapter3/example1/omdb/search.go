@@ -0,0 +1,145 @@
+package omdb
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// Number of results omdbapi.com return for each page of a search
+const PageSize = 10
+
+// One entry of the s= search endpoint
+type SearchResult struct {
+	Title  string `json:"Title"`
+	Year   string `json:"Year"`
+	ImdbID string `json:"imdbID"`
+	Type   string `json:"Type"`
+	Poster string `json:"Poster"`
+}
+
+// Parameters of a title search. Type is one of movie, series or episode.
+// Page start at 1, zero mean the first page.
+type SearchQuery struct {
+	Query string
+	Type  string
+	Year  string
+	Page  int
+}
+
+func (q SearchQuery) values() url.Values {
+	parms := url.Values{}
+	parms.Set("s", q.Query)
+	if q.Type != "" {
+		parms.Set("type", q.Type)
+	}
+	if q.Year != "" {
+		parms.Set("y", q.Year)
+	}
+	if q.Page > 1 {
+		parms.Set("page", strconv.Itoa(q.Page))
+	}
+	return parms
+}
+
+// One page of search results
+type SearchPage struct {
+	Results      []SearchResult `json:"Search"`
+	TotalResults int            `json:"totalResults,string"`
+	Page         int            `json:"-"`
+}
+
+// Number of pages needed to get all the results
+func (p *SearchPage) Pages() int {
+	return (p.TotalResults + PageSize - 1) / PageSize
+}
+
+// Return a single page of results for the query
+func (c *Client) Search(ctx context.Context, q SearchQuery) (*SearchPage, error) {
+	page := &SearchPage{}
+	if err := c.get(ctx, q.values(), page); err != nil {
+		return nil, err
+	}
+	page.Page = q.Page
+	if page.Page < 1 {
+		page.Page = 1
+	}
+	return page, nil
+}
+
+// Walk every result of a search, fetching the next page when needed.
+//
+//	it := client.SearchIterator(ctx, omdb.SearchQuery{Query: "Game of"})
+//	for it.Next() {
+//		fmt.Println(it.Result().Title)
+//	}
+//	if it.Err() != nil { ... }
+type SearchIterator struct {
+	ctx     context.Context
+	client  *Client
+	query   SearchQuery
+	results []SearchResult
+	current SearchResult
+	total   int
+	fetched int
+	done    bool
+	err     error
+}
+
+// Create an iterator starting at q.Page
+func (c *Client) SearchIterator(ctx context.Context, q SearchQuery) *SearchIterator {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	return &SearchIterator{ctx: ctx, client: c, query: q}
+}
+
+// Advance to the next result. Return false when there are no more
+// results or an error happened.
+func (it *SearchIterator) Next() bool {
+	for len(it.results) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		page, err := it.client.Search(it.ctx, it.query)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.total = page.TotalResults
+		it.results = page.Results
+		it.fetched += len(page.Results)
+		it.query.Page++
+		if len(page.Results) == 0 || it.query.Page > page.Pages() || it.fetched >= it.total {
+			it.done = true
+		}
+	}
+	it.current = it.results[0]
+	it.results = it.results[1:]
+	return true
+}
+
+// The result Next moved to
+func (it *SearchIterator) Result() SearchResult {
+	return it.current
+}
+
+// Total number of results reported by the API, known after the first Next
+func (it *SearchIterator) Total() int {
+	return it.total
+}
+
+// The error that stopped the iteration, if any
+func (it *SearchIterator) Err() error {
+	return it.err
+}
+
+// Return every result of the search across all pages
+func (c *Client) SearchAll(ctx context.Context, q SearchQuery) ([]SearchResult, error) {
+	it := c.SearchIterator(ctx, q)
+	results := []SearchResult{}
+	for it.Next() {
+		results = append(results, it.Result())
+	}
+	return results, it.Err()
+}
@@ -0,0 +1,142 @@
+package omdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Episode as listed in a season
+type Episode struct {
+	Title      string
+	Released   time.Time
+	Episode    int
+	ImdbRating float64
+	ImdbID     string
+}
+
+type episodeJSON struct {
+	Title      string `json:"Title"`
+	Released   string `json:"Released"`
+	Episode    string `json:"Episode"`
+	ImdbRating string `json:"imdbRating"`
+	ImdbID     string `json:"imdbID"`
+}
+
+func (e *Episode) UnmarshalJSON(data []byte) error {
+	raw := episodeJSON{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*e = Episode{
+		Title:      text(raw.Title),
+		Released:   parseDate(raw.Released),
+		Episode:    int(parseNumber(raw.Episode)),
+		ImdbRating: parseFloat(raw.ImdbRating),
+		ImdbID:     text(raw.ImdbID),
+	}
+	return nil
+}
+
+func (e Episode) MarshalJSON() ([]byte, error) {
+	raw := episodeJSON{
+		Title:      e.Title,
+		Released:   NotAvailable,
+		Episode:    strconv.Itoa(e.Episode),
+		ImdbRating: NotAvailable,
+		ImdbID:     e.ImdbID,
+	}
+	if !e.Released.IsZero() {
+		raw.Released = e.Released.Format(EpisodeDateLayout)
+	}
+	if e.ImdbRating > 0 {
+		raw.ImdbRating = strconv.FormatFloat(e.ImdbRating, 'f', 1, 64)
+	}
+	return json.Marshal(raw)
+}
+
+// Episode list of one season of a series
+type Season struct {
+	Title        string
+	Season       int
+	TotalSeasons int
+	Episodes     []Episode
+}
+
+type seasonJSON struct {
+	Title        string    `json:"Title"`
+	Season       string    `json:"Season"`
+	TotalSeasons string    `json:"totalSeasons"`
+	Episodes     []Episode `json:"Episodes"`
+}
+
+func (s *Season) UnmarshalJSON(data []byte) error {
+	raw := seasonJSON{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*s = Season{
+		Title:        text(raw.Title),
+		Season:       int(parseNumber(raw.Season)),
+		TotalSeasons: int(parseNumber(raw.TotalSeasons)),
+		Episodes:     raw.Episodes,
+	}
+	return nil
+}
+
+func (s Season) MarshalJSON() ([]byte, error) {
+	raw := seasonJSON{
+		Title:        s.Title,
+		Season:       strconv.Itoa(s.Season),
+		TotalSeasons: strconv.Itoa(s.TotalSeasons),
+		Episodes:     s.Episodes,
+	}
+	if raw.Episodes == nil {
+		raw.Episodes = []Episode{}
+	}
+	return json.Marshal(raw)
+}
+
+// Return the episode list of a season of the series with the IMDb id
+func (c *Client) GetSeason(ctx context.Context, id string, season int) (*Season, error) {
+	parms := url.Values{}
+	parms.Set("i", id)
+	parms.Set("Season", strconv.Itoa(season))
+	s := &Season{}
+	if err := c.get(ctx, parms, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Return the full detail of one episode of the series with the IMDb id
+func (c *Client) GetEpisode(ctx context.Context, id string, season, episode int) (*MovieInfo, error) {
+	parms := url.Values{}
+	parms.Set("i", id)
+	parms.Set("Season", strconv.Itoa(season))
+	parms.Set("Episode", strconv.Itoa(episode))
+	mi := &MovieInfo{}
+	if err := c.get(ctx, parms, mi); err != nil {
+		return nil, err
+	}
+	return mi, nil
+}
+
+// Return every season of a series, using the totalSeasons of the first season
+func (c *Client) GetAllSeasons(ctx context.Context, id string) ([]*Season, error) {
+	first, err := c.GetSeason(ctx, id, 1)
+	if err != nil {
+		return nil, err
+	}
+	seasons := []*Season{first}
+	for n := 2; n <= first.TotalSeasons; n++ {
+		season, err := c.GetSeason(ctx, id, n)
+		if err != nil {
+			return seasons, err
+		}
+		seasons = append(seasons, season)
+	}
+	return seasons, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+func newTabWriter(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 1, 5, 2, ' ', 0)
+}
+
+// Write the rows as an aligned table, the first row is the header
+func writeTable(w io.Writer, rows [][]string) error {
+	writer := newTabWriter(w)
+	for _, row := range rows {
+		writer.Write([]byte(strings.Join(row, "\t") + "\t\n"))
+	}
+	return writer.Flush()
+}
+
+func writeCSV(w io.Writer, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	writer.WriteAll(rows)
+	return writer.Error()
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// Table and CSV output of a value: the rows, the first one being the header
+func writeRows(env *environment, v interface{}, rows [][]string) error {
+	switch env.format {
+	case "json":
+		return writeJSON(env.stdout, v)
+	case "csv":
+		return writeCSV(env.stdout, rows)
+	}
+	return writeTable(env.stdout, rows)
+}
+
+func writeMovie(env *environment, mi *omdb.MovieInfo) error {
+	values := mi.Fields(omdb.FieldNames)
+	if env.format == "table" {
+		rows := [][]string{}
+		for i, name := range omdb.FieldNames {
+			if values[i] != "" {
+				rows = append(rows, []string{name + ":", values[i]})
+			}
+		}
+		for _, rating := range mi.Ratings {
+			rows = append(rows, []string{rating.Source + ":", rating.Value})
+		}
+		return writeTable(env.stdout, rows)
+	}
+	return writeRows(env, mi, [][]string{omdb.FieldNames, values})
+}
+
+func writeSearchResults(env *environment, results []omdb.SearchResult) error {
+	rows := [][]string{{"imdbID", "Title", "Year", "Type"}}
+	for _, r := range results {
+		rows = append(rows, []string{r.ImdbID, r.Title, r.Year, r.Type})
+	}
+	return writeRows(env, results, rows)
+}
+
+func writeSeason(env *environment, season *omdb.Season) error {
+	rows := [][]string{{"Episode", "Title", "Released", "imdbRating", "imdbID"}}
+	for _, e := range season.Episodes {
+		released, rating := "", ""
+		if !e.Released.IsZero() {
+			released = e.Released.Format(omdb.EpisodeDateLayout)
+		}
+		if e.ImdbRating > 0 {
+			rating = strconv.FormatFloat(e.ImdbRating, 'f', 1, 64)
+		}
+		rows = append(rows, []string{strconv.Itoa(e.Episode), e.Title, released, rating, e.ImdbID})
+	}
+	return writeRows(env, season, rows)
+}
@@ -0,0 +1,224 @@
+// Package poster download movie posters and generate their thumbnails.
+package poster
+
+/*
+The posters are stored under a content addressed path, the sha256 of the
+image: <dir>/ab/abcdef...jpg, and the thumbnails next to them:
+<dir>/ab/abcdef...-w100.jpg. Downloading the same poster twice reuse the
+files already written.
+*/
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+var ErrNoPoster = errors.New("poster: movie has no poster")
+
+// Largest poster accepted, omdbapi.com posters are around 50KB
+const MaxSize = 20 << 20
+
+// Largest number of pixels of a poster, checked before decoding it: a small
+// file can declare an image needing gigabytes once decoded
+const MaxPixels = 25 << 20
+
+type Downloader struct {
+	HTTPClient *http.Client
+	UserAgent  string
+	// Root directory of the posters
+	Dir string
+	// Widths of the thumbnails in pixels, thumbnails are never wider than the poster
+	Widths []int
+}
+
+type Result struct {
+	// Hex sha256 of the poster
+	Hash string
+	// Path of the poster
+	Path string
+	// Path of the thumbnail of each width
+	Thumbnails map[int]string
+}
+
+// Create a downloader using the HTTP client and user agent of the omdb client
+func New(client *omdb.Client, dir string, widths ...int) *Downloader {
+	return &Downloader{HTTPClient: client.HTTPClient, UserAgent: client.UserAgent, Dir: dir, Widths: widths}
+}
+
+// Download the poster of the movie and generate its thumbnails
+func (d *Downloader) Download(ctx context.Context, mi *omdb.MovieInfo) (*Result, error) {
+	if mi.Poster == "" || mi.Poster == omdb.NotAvailable {
+		return nil, ErrNoPoster
+	}
+	data, err := d.fetch(ctx, mi.Poster)
+	if err != nil {
+		return nil, err
+	}
+	return d.Store(data)
+}
+
+func (d *Downloader) fetch(ctx context.Context, posterURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, posterURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if d.UserAgent != "" {
+		req.Header.Set("User-Agent", d.UserAgent)
+	}
+	client := d.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("poster: %s: %s", posterURL, resp.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxSize {
+		return nil, fmt.Errorf("poster: %s: larger than %d bytes", posterURL, MaxSize)
+	}
+	return data, nil
+}
+
+// Store an image already downloaded and generate its thumbnails
+func (d *Downloader) Store(data []byte) (*Result, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("poster: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, errors.New("poster: empty image")
+	}
+	if config.Width*config.Height > MaxPixels || config.Width > MaxPixels || config.Height > MaxPixels {
+		return nil, fmt.Errorf("poster: %dx%d image larger than %d pixels", config.Width, config.Height, MaxPixels)
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("poster: %w", err)
+	}
+	ext := ".jpg"
+	if format == "png" || format == "gif" {
+		ext = "." + format
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	base := filepath.Join(d.Dir, hash[:2], hash)
+	result := &Result{Hash: hash, Path: base + ext, Thumbnails: map[int]string{}}
+	if err := os.MkdirAll(filepath.Dir(base), 0755); err != nil {
+		return nil, err
+	}
+	if err := writeIfMissing(result.Path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	thumbExt := ".jpg"
+	if format == "png" || format == "gif" {
+		thumbExt = ".png"
+	}
+	for _, width := range d.Widths {
+		if width <= 0 {
+			continue
+		}
+		path := base + "-w" + strconv.Itoa(width) + thumbExt
+		err := writeIfMissing(path, func(w io.Writer) error {
+			thumb := Resize(img, width)
+			if thumbExt == ".png" {
+				return png.Encode(w, thumb)
+			}
+			return jpeg.Encode(w, thumb, &jpeg.Options{Quality: 85})
+		})
+		if err != nil {
+			return nil, err
+		}
+		result.Thumbnails[width] = path
+	}
+	return result, nil
+}
+
+// Write the file through a temporary file unless it already exist
+func writeIfMissing(path string, write func(w io.Writer) error) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".poster-*")
+	if err != nil {
+		return err
+	}
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Scale the image down to the width keeping the aspect ratio, each pixel of
+// the thumbnail is the average of the pixels it cover. Images narrower than
+// the width are only copied, an empty image give an empty image.
+func Resize(src image.Image, width int) *image.RGBA {
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	if sw == 0 || sh == 0 {
+		return image.NewRGBA(image.Rect(0, 0, 0, 0))
+	}
+	if width >= sw {
+		width = sw
+	}
+	height := sh * width / sw
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, (y+1)*sh/height
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, (x+1)*sw/width
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(r / n >> 8), uint8(g / n >> 8), uint8(b / n >> 8), uint8(a / n >> 8)})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,76 @@
+package poster
+
+import (
+	"bytes"
+	"context"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestDownload(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 450))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	img.SetRGBA(0, 0, color.RGBA{0, 0, 0, 0xff})
+	buf := &bytes.Buffer{}
+	png.Encode(buf, img)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "posters")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d := New(omdb.NewClient("", srv.Client(), "", ""), dir, 100, 600)
+	result, err := d.Download(context.Background(), &omdb.MovieInfo{Poster: srv.URL + "/poster.png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for width, want := range map[int]image.Point{100: {100, 150}, 600: {300, 450}} {
+		file, err := os.Open(result.Thumbnails[width])
+		if err != nil {
+			t.Fatal(err)
+		}
+		config, _, err := image.DecodeConfig(file)
+		file.Close()
+		if err != nil || config.Width != want.X || config.Height != want.Y {
+			t.Errorf("thumbnail %d: got %dx%d %v, want %v", width, config.Width, config.Height, err, want)
+		}
+	}
+	if _, err := d.Download(context.Background(), &omdb.MovieInfo{}); err != ErrNoPoster {
+		t.Errorf("got %v, want ErrNoPoster", err)
+	}
+}
+
+func TestStoreRejectedImages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "posters")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	d := &Downloader{Dir: dir, Widths: []int{100}}
+
+	// GIF headers declaring a 65535x65535 and a 0x0 logical screen
+	gif := func(width, height byte) []byte {
+		return []byte{'G', 'I', 'F', '8', '9', 'a', width, width, height, height, 0, 0, 0}
+	}
+	for _, data := range [][]byte{gif(0xff, 0xff), gif(0, 0)} {
+		if _, err := d.Store(data); err == nil {
+			t.Errorf("%x: expected an error", data)
+		}
+	}
+	if thumb := Resize(image.NewRGBA(image.Rect(0, 0, 0, 10)), 100); thumb.Bounds().Dx() != 0 {
+		t.Errorf("got %v for an empty image", thumb.Bounds())
+	}
+}
@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"reflect"
+	"strings"
+)
+
+// Provider querying several providers in priority order and merging their
+// answers: a field is taken from the first provider that has a value for it.
+// A provider failing, for example omdbapi.com being down, is skipped.
+type Composite struct {
+	Providers []MovieProvider
+}
+
+func NewComposite(providers ...MovieProvider) *Composite {
+	return &Composite{Providers: providers}
+}
+
+// A movie merged from several providers
+type Merged struct {
+	Movie *omdb.MovieInfo
+	// Name of the provider of each field set, by MovieInfo field name, example: "Plot"
+	Sources map[string]string
+	// Errors of the providers that failed for another reason than not found
+	Errors map[string]error
+}
+
+func (c *Composite) Name() string {
+	names := make([]string, len(c.Providers))
+	for i, p := range c.Providers {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+func (c *Composite) GetByID(ctx context.Context, id string) (*omdb.MovieInfo, error) {
+	merged, err := c.Lookup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return merged.Movie, nil
+}
+
+func (c *Composite) FindByTitle(ctx context.Context, q omdb.TitleQuery) (*omdb.MovieInfo, error) {
+	merged, err := c.LookupTitle(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return merged.Movie, nil
+}
+
+// Ask every provider for the movie and merge the answers
+func (c *Composite) Lookup(ctx context.Context, id string) (*Merged, error) {
+	return c.merge(ctx, func(p MovieProvider) (*omdb.MovieInfo, error) {
+		return p.GetByID(ctx, id)
+	})
+}
+
+// Find the title with the first provider that know it, then ask the next
+// providers for the same IMDb id so they can't pick another movie
+func (c *Composite) LookupTitle(ctx context.Context, q omdb.TitleQuery) (*Merged, error) {
+	id := ""
+	return c.merge(ctx, func(p MovieProvider) (*omdb.MovieInfo, error) {
+		if id != "" {
+			return p.GetByID(ctx, id)
+		}
+		mi, err := p.FindByTitle(ctx, q)
+		if err == nil {
+			id = mi.ImdbID
+		}
+		return mi, err
+	})
+}
+
+func (c *Composite) merge(ctx context.Context, get func(p MovieProvider) (*omdb.MovieInfo, error)) (*Merged, error) {
+	merged := &Merged{Sources: map[string]string{}, Errors: map[string]error{}}
+	for _, p := range c.Providers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		mi, err := get(p)
+		if errors.Is(err, omdb.ErrNotFound) {
+			continue
+		} else if err != nil {
+			merged.Errors[p.Name()] = err
+			continue
+		}
+		if merged.Movie == nil {
+			merged.Movie = &omdb.MovieInfo{}
+		}
+		mergeFields(merged.Movie, mi, p.Name(), merged.Sources)
+	}
+	if merged.Movie == nil {
+		return nil, c.failure(merged.Errors)
+	}
+	return merged, nil
+}
+
+// Set the zero fields of dst from src and record the source of each field set
+func mergeFields(dst, src *omdb.MovieInfo, source string, sources map[string]string) {
+	d, s := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	for i := 0; i < d.NumField(); i++ {
+		if !d.Field(i).IsZero() || s.Field(i).IsZero() {
+			continue
+		}
+		d.Field(i).Set(s.Field(i))
+		sources[d.Type().Field(i).Name] = source
+	}
+}
+
+// Error when no provider had the movie: the error of the first provider that
+// failed, not found when they all answered
+func (c *Composite) failure(errs map[string]error) error {
+	for _, p := range c.Providers {
+		if err, ok := errs[p.Name()]; ok {
+			return fmt.Errorf("%s: %w", p.Name(), err)
+		}
+	}
+	return omdb.ErrNotFound
+}
+
+// Merge the results of every provider, in priority order without duplicates
+func (c *Composite) Search(ctx context.Context, q omdb.SearchQuery) ([]omdb.SearchResult, error) {
+	seen := map[string]bool{}
+	results := []omdb.SearchResult{}
+	errs := map[string]error{}
+	for _, p := range c.Providers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		found, err := p.Search(ctx, q)
+		if errors.Is(err, omdb.ErrNotFound) {
+			continue
+		} else if err != nil {
+			errs[p.Name()] = err
+			continue
+		}
+		for _, result := range found {
+			if id := strings.ToLower(result.ImdbID); !seen[id] {
+				seen[id] = true
+				results = append(results, result)
+			}
+		}
+	}
+	if len(results) == 0 {
+		return nil, c.failure(errs)
+	}
+	return results, nil
+}
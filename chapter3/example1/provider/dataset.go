@@ -0,0 +1,189 @@
+package provider
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Provider answering from movies held in memory, usually an offline dump
+// of omdbapi.com loaded with LoadDataset. It never do any I/O once loaded.
+type Dataset struct {
+	name   string
+	movies []*omdb.MovieInfo
+	byID   map[string]*omdb.MovieInfo
+}
+
+// Create a dataset of the movies, a later movie with the same IMDb id
+// replace the earlier one
+func NewDataset(name string, movies []*omdb.MovieInfo) *Dataset {
+	d := &Dataset{name: name, byID: map[string]*omdb.MovieInfo{}}
+	index := map[string]int{}
+	for _, mi := range movies {
+		id := strings.ToLower(mi.ImdbID)
+		if id == "" {
+			continue
+		}
+		if i, ok := index[id]; ok {
+			d.movies[i] = mi
+		} else {
+			index[id] = len(d.movies)
+			d.movies = append(d.movies, mi)
+		}
+		d.byID[id] = mi
+	}
+	return d
+}
+
+// Load a dataset from a file. A .csv file has a header line of OMDb field
+// names, see omdb.FieldNames, other columns are ignored. Any other file hold
+// OMDb documents, either in a JSON array or one after the other like NDJSON.
+// The name of the dataset is the name of the file.
+func LoadDataset(path string) (*Dataset, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var movies []*omdb.MovieInfo
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		movies, err = readCSV(file)
+	} else {
+		movies, err = readJSON(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return NewDataset(filepath.Base(path), movies), nil
+}
+
+func readCSV(r io.Reader) ([]*omdb.MovieInfo, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	hasID := false
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+		hasID = hasID || strings.EqualFold(header[i], "imdbID")
+	}
+	if !hasID {
+		return nil, errors.New("CSV without an imdbID column")
+	}
+	movies := []*omdb.MovieInfo{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return movies, nil
+		} else if err != nil {
+			return nil, err
+		}
+		movies = append(movies, omdb.MovieFromFields(header, record))
+	}
+}
+
+func readJSON(r io.Reader) ([]*omdb.MovieInfo, error) {
+	decoder := json.NewDecoder(r)
+	movies := []*omdb.MovieInfo{}
+	for {
+		raw := json.RawMessage{}
+		if err := decoder.Decode(&raw); err == io.EOF {
+			return movies, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(strings.TrimSpace(string(raw)), "[") {
+			list := []*omdb.MovieInfo{}
+			if err := json.Unmarshal(raw, &list); err != nil {
+				return nil, err
+			}
+			movies = append(movies, list...)
+			continue
+		}
+		mi := &omdb.MovieInfo{}
+		if err := json.Unmarshal(raw, mi); err != nil {
+			return nil, err
+		}
+		movies = append(movies, mi)
+	}
+}
+
+func (d *Dataset) Name() string {
+	return d.name
+}
+
+func (d *Dataset) Len() int {
+	return len(d.movies)
+}
+
+func (d *Dataset) GetByID(ctx context.Context, id string) (*omdb.MovieInfo, error) {
+	mi, ok := d.byID[strings.ToLower(strings.TrimSpace(id))]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s not in %s", omdb.ErrNotFound, id, d.name)
+	}
+	return copyMovie(mi), nil
+}
+
+func (d *Dataset) FindByTitle(ctx context.Context, q omdb.TitleQuery) (*omdb.MovieInfo, error) {
+	title := strings.TrimSpace(q.Title)
+	for _, mi := range d.movies {
+		if strings.EqualFold(mi.Title, title) && match(mi, q.Year, q.Type) {
+			return copyMovie(mi), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q not in %s", omdb.ErrNotFound, title, d.name)
+}
+
+// Like the s= search of omdbapi.com the titles containing the query are
+// returned by pages of omdb.PageSize, episodes are left out
+func (d *Dataset) Search(ctx context.Context, q omdb.SearchQuery) ([]omdb.SearchResult, error) {
+	query := strings.ToLower(strings.TrimSpace(q.Query))
+	found := []omdb.SearchResult{}
+	for _, mi := range d.movies {
+		if mi.Type != "episode" && strings.Contains(strings.ToLower(mi.Title), query) && match(mi, q.Year, q.Type) {
+			found = append(found, omdb.SearchResult{Title: mi.Title, Year: mi.Year, ImdbID: mi.ImdbID, Type: mi.Type, Poster: mi.Poster})
+		}
+	}
+	page := q.Page
+	if page < 1 {
+		page = 1
+	}
+	start := (page - 1) * omdb.PageSize
+	if query == "" || start >= len(found) {
+		return nil, fmt.Errorf("%w: no title containing %q in %s", omdb.ErrNotFound, q.Query, d.name)
+	}
+	end := start + omdb.PageSize
+	if end > len(found) {
+		end = len(found)
+	}
+	return found[start:end], nil
+}
+
+func match(mi *omdb.MovieInfo, year, kind string) bool {
+	if kind != "" && !strings.EqualFold(mi.Type, kind) {
+		return false
+	}
+	return year == "" || strings.HasPrefix(mi.Year, year)
+}
+
+// The callers may change the movie, the dataset keep its own copy
+func copyMovie(mi *omdb.MovieInfo) *omdb.MovieInfo {
+	c := *mi
+	c.Genre = append([]string(nil), mi.Genre...)
+	c.Director = append([]string(nil), mi.Director...)
+	c.Writer = append([]string(nil), mi.Writer...)
+	c.Actors = append([]string(nil), mi.Actors...)
+	c.Language = append([]string(nil), mi.Language...)
+	c.Country = append([]string(nil), mi.Country...)
+	c.Ratings = append([]omdb.Rating(nil), mi.Ratings...)
+	return &c
+}
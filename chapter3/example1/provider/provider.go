@@ -0,0 +1,65 @@
+// Package provider abstract where the movie metadata come from, so the
+// tools are not tied to omdbapi.com.
+package provider
+
+/*
+A MovieProvider answer lookups by IMDb id or title and title searches.
+The package has three implementations:
+
+	OMDb       the omdbapi.com API through an omdb.Client
+	Dataset    an offline dump of movies loaded from a JSON or CSV file
+	Composite  several providers queried in priority order, the fields
+	           missing from the first answer are filled by the next ones
+
+	p := provider.NewComposite(provider.NewOMDb(client), dataset)
+	merged, err := p.Lookup(ctx, "tt1375666")
+	...
+	fmt.Println(merged.Sources["Plot"])
+
+Every provider return an error matching omdb.ErrNotFound with errors.Is
+when the movie is unknown.
+*/
+
+import (
+	"context"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+)
+
+type MovieProvider interface {
+	// Short name of the backend, recorded as the source of the merged fields
+	Name() string
+	GetByID(ctx context.Context, id string) (*omdb.MovieInfo, error)
+	// Return the movie with the title, Year and Type of the query narrow the match
+	FindByTitle(ctx context.Context, q omdb.TitleQuery) (*omdb.MovieInfo, error)
+	// Return one page of the titles containing the query
+	Search(ctx context.Context, q omdb.SearchQuery) ([]omdb.SearchResult, error)
+}
+
+// Provider backed by omdbapi.com
+type OMDb struct {
+	Client *omdb.Client
+}
+
+func NewOMDb(client *omdb.Client) *OMDb {
+	return &OMDb{Client: client}
+}
+
+func (p *OMDb) Name() string {
+	return "omdb"
+}
+
+func (p *OMDb) GetByID(ctx context.Context, id string) (*omdb.MovieInfo, error) {
+	return p.Client.SearchById(ctx, id)
+}
+
+func (p *OMDb) FindByTitle(ctx context.Context, q omdb.TitleQuery) (*omdb.MovieInfo, error) {
+	return p.Client.SearchByTitle(ctx, q)
+}
+
+func (p *OMDb) Search(ctx context.Context, q omdb.SearchQuery) ([]omdb.SearchResult, error) {
+	page, err := p.Client.Search(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return page.Results, nil
+}
@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb/omdbtest"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const datasetJSON = `[
+	{"Title":"Inception","Year":"2010","Production":"Warner Bros.","Plot":"A dream heist.","imdbID":"tt1375666","Type":"movie"},
+	{"Title":"Inception: The Cobol Job","Year":"2010","imdbID":"tt5295894","Type":"movie"}
+]
+{"Title":"Alien","Year":"1979","Director":"Ridley Scott","imdbID":"tt0078748","Type":"movie"}
+`
+
+const datasetCSV = `imdbID,Title,Year,Genre,imdbRating,MyRating
+tt0078748,Alien,1979,"Horror, Sci-Fi",8.5,9
+tt1375666,Inception,2010,,,
+`
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDataset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dataset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+
+	for _, name := range []string{"movies.json", "movies.csv"} {
+		content := datasetJSON
+		if filepath.Ext(name) == ".csv" {
+			content = datasetCSV
+		}
+		d, err := LoadDataset(writeFile(t, dir, name, content))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if d.Name() != name || d.Len() < 2 {
+			t.Errorf("%s: got name %q and %d movies", name, d.Name(), d.Len())
+		}
+		mi, err := d.GetByID(ctx, "TT0078748")
+		if err != nil || mi.Title != "Alien" || mi.StartYear() != 1979 {
+			t.Errorf("%s: got %+v, %v", name, mi, err)
+		}
+		if _, err := d.FindByTitle(ctx, omdb.TitleQuery{Title: "alien", Year: "1980"}); !errors.Is(err, omdb.ErrNotFound) {
+			t.Errorf("%s: got %v, want not found", name, err)
+		}
+	}
+
+	d, _ := LoadDataset(filepath.Join(dir, "movies.json"))
+	results, err := d.Search(ctx, omdb.SearchQuery{Query: "incep"})
+	if err != nil || len(results) != 2 {
+		t.Errorf("search: got %v, %v", results, err)
+	}
+	if _, err := LoadDataset(writeFile(t, dir, "bad.csv", "Title\nAlien\n")); err == nil {
+		t.Error("CSV without imdbID: got no error")
+	}
+}
+
+func TestComposite(t *testing.T) {
+	srv := omdbtest.MustNewServer(omdbtest.FixtureDir())
+	defer srv.Close()
+	client := omdb.NewClient(srv.URL, srv.Client(), omdbtest.APIKey, "")
+	client.Retry = omdb.RetryPolicy{MaxAttempts: 1}
+	dataset := NewDataset("dump", []*omdb.MovieInfo{
+		{ImdbID: "tt1375666", Title: "Inception", Year: "2010", Production: "Warner Bros.", Plot: "A dream heist."},
+		{ImdbID: "tt0078748", Title: "Alien", Year: "1979", Type: "movie"},
+	})
+	c := NewComposite(NewOMDb(client), dataset)
+	ctx := context.Background()
+
+	merged, err := c.Lookup(ctx, "tt1375666")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mi := merged.Movie
+	if mi.Production != "Warner Bros." || merged.Sources["Production"] != "dump" {
+		t.Errorf("missing field not filled: %q from %q", mi.Production, merged.Sources["Production"])
+	}
+	if mi.Plot == "A dream heist." || merged.Sources["Plot"] != "omdb" || merged.Sources["ImdbRating"] != "omdb" {
+		t.Errorf("field of the first provider replaced: %q, sources %v", mi.Plot, merged.Sources)
+	}
+
+	merged, err = c.LookupTitle(ctx, omdb.TitleQuery{Title: "alien"})
+	if err != nil || merged.Movie.ImdbID != "tt0078748" || merged.Sources["Title"] != "dump" {
+		t.Errorf("title only in the dataset: got %+v, %v", merged, err)
+	}
+
+	srv.FailNext(1, http.StatusInternalServerError, "")
+	merged, err = c.Lookup(ctx, "tt1375666")
+	if err != nil || merged.Movie.Title != "Inception" || merged.Errors["omdb"] == nil {
+		t.Errorf("omdb down: got %+v, %v", merged, err)
+	}
+	srv.FailNext(1, http.StatusInternalServerError, "")
+	if _, err := c.Lookup(ctx, "tt0000001"); err == nil || errors.Is(err, omdb.ErrNotFound) {
+		t.Errorf("omdb down and movie not in the dataset: got %v, want the omdb error", err)
+	}
+	if _, err := c.Lookup(ctx, "tt0000001"); !errors.Is(err, omdb.ErrNotFound) {
+		t.Errorf("got %v, want not found", err)
+	}
+
+	results, err := c.Search(ctx, omdb.SearchQuery{Query: "incep"})
+	if err != nil || len(results) != 1 || results[0].ImdbID != "tt1375666" {
+		t.Errorf("search: got %v, %v", results, err)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/gateway"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/omdb"
+	"net/http"
+	"time"
+)
+
+// Run the gateway service, the client get an in memory cache in front of
+// the optional -cache directory.
+func setupServe(fs *flag.FlagSet) runFunc {
+	addr := fs.String("addr", ":8080", "address to listen on")
+	cacheSize := fs.Int("cache-size", 10000, "number of answers kept in memory")
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) != 0 {
+			return usageError("unexpected arguments")
+		}
+		memory := omdb.NewLRUCache(*cacheSize)
+		if disk, ok := env.client.Cache.(*omdb.TieredCache); ok {
+			env.client.Cache = omdb.NewTieredCache(memory, disk.Back)
+		} else {
+			env.client.Cache = memory
+		}
+		srv := &http.Server{Addr: *addr, Handler: gateway.New(env.client)}
+		errs := make(chan error, 1)
+		go func() {
+			errs <- srv.ListenAndServe()
+		}()
+		fmt.Fprintln(env.stderr, "listening on", *addr)
+		select {
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+			shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			return srv.Shutdown(shutdown)
+		}
+	}
+}
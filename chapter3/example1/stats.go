@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/Tanmay-Teaches/golang/chapter3/example1/library"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Width of the longest bar and height of the histogram, in characters
+const (
+	barWidth        = 30
+	histogramHeight = 8
+)
+
+// Header of the first column of the table of each dimension
+var dimensionHeaders = map[string]string{
+	library.ByGenre:    "Genre",
+	library.ByDecade:   "Decade",
+	library.ByCountry:  "Country",
+	library.ByLanguage: "Language",
+	library.ByRated:    "Rated",
+	library.ByDirector: "Director",
+}
+
+// Aggregate the library by genre, decade, country, language, rated and director
+func setupStats(fs *flag.FlagSet) runFunc {
+	path := libraryFlag(fs)
+	by := fs.String("by", strings.Join(library.Dimensions, ","), "comma separated dimensions to show")
+	top := fs.Int("top", 10, "number of groups shown for each dimension, 0 for all")
+	genre := fs.String("genre", "", "only this genre")
+	from := fs.Int("from", 0, "only the movies released this year or later")
+	to := fs.Int("to", 0, "only the movies released this year or before")
+	tag := fs.String("tag", "", "only the movies with this tag")
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) != 0 {
+			return usageError("unexpected arguments")
+		}
+		dims := splitList(*by)
+		for _, dim := range dims {
+			if !containsString(library.Dimensions, dim) {
+				return usageError(fmt.Sprintf("unknown dimension %q, expected one of %s", dim, strings.Join(library.Dimensions, ", ")))
+			}
+		}
+		store, err := library.Open(*path)
+		if err != nil {
+			return err
+		}
+		stats := library.ComputeStats(store.List(library.Filter{Genre: *genre, YearFrom: *from, YearTo: *to, Tag: *tag}))
+		groups := map[string][]library.Group{}
+		for _, dim := range dims {
+			list := stats.Groups[dim]
+			if *top > 0 && len(list) > *top {
+				list = list[:*top]
+			}
+			groups[dim] = list
+		}
+		stats.Groups = groups
+
+		switch env.format {
+		case "json":
+			return writeJSON(env.stdout, stats)
+		case "csv":
+			rows := [][]string{{"Dimension", "Name", "Movies", "imdbRating", "RuntimeMinutes"}}
+			for _, dim := range dims {
+				for _, g := range groups[dim] {
+					rows = append(rows, []string{dim, g.Name, strconv.Itoa(g.Count),
+						formatRating(g.AverageRating), strconv.Itoa(g.RuntimeMinutes)})
+				}
+			}
+			return writeCSV(env.stdout, rows)
+		}
+		return writeStats(env.stdout, stats, dims)
+	}
+}
+
+// Write the totals, a table with bars for each dimension and the histogram of imdbRating
+func writeStats(w io.Writer, stats *library.Stats, dims []string) error {
+	fmt.Fprintf(w, "%d movies, %d rated, average imdbRating %s, total runtime %s\n",
+		stats.Movies, stats.Rated, formatRating(stats.AverageRating), formatMinutes(stats.RuntimeMinutes))
+	for _, dim := range dims {
+		groups := stats.Groups[dim]
+		if len(groups) == 0 {
+			continue
+		}
+		max := 0
+		for _, g := range groups {
+			if g.Count > max {
+				max = g.Count
+			}
+		}
+		fmt.Fprintf(w, "\nBy %s:\n", dim)
+		rows := [][]string{{dimensionHeaders[dim], "Movies", "imdbRating", "Runtime", ""}}
+		for _, g := range groups {
+			rows = append(rows, []string{g.Name, strconv.Itoa(g.Count), formatRating(g.AverageRating),
+				formatMinutes(g.RuntimeMinutes), bar(g.Count, max, barWidth)})
+		}
+		if err := writeTable(w, rows); err != nil {
+			return err
+		}
+	}
+	if stats.Rated == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "\nimdbRating:\n")
+	return writeHistogram(w, stats.Ratings, histogramHeight)
+}
+
+// Return a bar of # proportional to value, at least one # for a value above 0
+func bar(value, max, width int) string {
+	if value <= 0 || max <= 0 {
+		return ""
+	}
+	n := value * width / max
+	if n == 0 {
+		n = 1
+	}
+	return strings.Repeat("#", n)
+}
+
+// Write the buckets as vertical bars, the counts on the left axis and the
+// lower bound of each bucket below:
+//
+//	3 |        #
+//	  |     #  #
+//	  +------------------------------
+//	    0  1  2  3  4  5  6  7  8  9
+func writeHistogram(w io.Writer, buckets []library.Bucket, height int) error {
+	max := 0
+	for _, b := range buckets {
+		if b.Count > max {
+			max = b.Count
+		}
+	}
+	if max < height {
+		height = max
+	}
+	axis := len(strconv.Itoa(max))
+	for row := height; row > 0; row-- {
+		label := ""
+		if row == height {
+			label = strconv.Itoa(max)
+		}
+		line := fmt.Sprintf("%*s |", axis, label)
+		for _, b := range buckets {
+			// Round up so that a bucket with one movie is visible
+			if (b.Count*height+max-1)/max >= row {
+				line += "  #"
+			} else {
+				line += "   "
+			}
+		}
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line, " ")); err != nil {
+			return err
+		}
+	}
+	labels := ""
+	for _, b := range buckets {
+		labels += fmt.Sprintf("%3s", strconv.FormatFloat(b.From, 'f', -1, 64))
+	}
+	_, err := fmt.Fprintf(w, "%*s +%s\n%*s  %s\n", axis, "", strings.Repeat("-", 3*len(buckets)), axis, "", labels)
+	return err
+}
+
+// Format an average rating, empty for none
+func formatRating(rating float64) string {
+	if rating == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(rating, 'f', 2, 64)
+}
+
+// Format minutes as hours and minutes, 125 is 2h05m
+func formatMinutes(minutes int) string {
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,12 @@
 module example2
 
-go 1.14
+go 1.18
 
 require (
-	github.com/otiai10/mint v1.3.2 // indirect
+	github.com/Tanmay-Teaches/golang/chapter3/example3 v0.0.0-20200817005752-e03d213ecc87
 	github.com/otiai10/primes v0.0.0-20180210170552-f6d2a1ba97c4
 )
+
+require github.com/otiai10/mint v1.3.2 // indirect
+
+replace github.com/Tanmay-Teaches/golang/chapter3/example3 => ../example3
@@ -11,9 +11,10 @@ Example of using third party package with go module
 */
 import (
 	"fmt"
+	"github.com/Tanmay-Teaches/golang/chapter3/example3"
 	"github.com/otiai10/primes"
+	"math/big"
 	"os"
-	"strconv"
 )
 
 func main() {
@@ -22,10 +23,16 @@ func main() {
 		println("Usage:", os.Args[0], "<number>")
 		os.Exit(1)
 	}
-	number, err := strconv.Atoi(args[0])
-	if err != nil {
-		panic(err)
+	number, ok := new(big.Int).SetString(args[0], 10)
+	if !ok {
+		println("Not a number:", args[0])
+		os.Exit(1)
+	}
+	// primes only handle int64, larger numbers are tested with math/big
+	if !number.IsInt64() {
+		fmt.Println("primes:", example3.ProbablyPrimeBig(number, 20))
+		return
 	}
-	f := primes.Factorize(int64(number))
+	f := primes.Factorize(number.Int64())
 	fmt.Println("primes:", len(f.Powers()) == 1)
 }
@@ -0,0 +1,333 @@
+package example3
+
+/*
+Primality and factorization of numbers of any size with math/big.
+
+FactorizeBig remove the small factors by trial division, then split what is
+left with Pollard's rho in the variant of Brent, which find factors up to
+about 10 digits quickly, and with the elliptic curve method (ECM) of Lenstra
+for larger factors. Both are tried on each composite part until it is prime.
+*/
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+)
+
+// Error of FactorizeBig when neither Pollard's rho nor ECM split a composite part
+var ErrFactorizationFailed = errors.New("example3: could not find a factor")
+
+// Trial division bound of FactorizeBig
+const trialDivisionLimit = 10000
+
+var (
+	bigOne = big.NewInt(1)
+	bigTwo = big.NewInt(2)
+	// Primes below trialDivisionLimit
+	trialPrimes = primesBelow(trialDivisionLimit)
+)
+
+// Budget of Pollard's rho, in iterations for each polynomial
+const (
+	rhoIterations  = 1 << 18
+	rhoPolynomials = 2
+)
+
+// Stages of ECM: the bound B1 and the number of curves tried with it,
+// the bounds are the optimal ones for factors of 15, 20 and 25 digits
+var ecmStages = []struct {
+	b1     uint64
+	curves int
+}{
+	{2000, 25},
+	{11000, 90},
+	{50000, 300},
+}
+
+func primesBelow(limit uint64) []uint64 {
+	list := []uint64{}
+	for n := uint64(2); n < limit; n++ {
+		if isPrime64(n) {
+			list = append(list, n)
+		}
+	}
+	return list
+}
+
+// Report if n is prime. Numbers below 2^64 get the exact answer of IsPrime,
+// larger ones pass rounds Miller–Rabin rounds with random bases and the
+// Baillie-PSW test, a composite pass with a probability below 4^-rounds.
+func ProbablyPrimeBig(n *big.Int, rounds int) bool {
+	if n.Sign() <= 0 {
+		return false
+	}
+	if n.IsUint64() {
+		return isPrime64(n.Uint64())
+	}
+	if rounds < 0 {
+		rounds = 0
+	}
+	return n.ProbablyPrime(rounds)
+}
+
+// Return the prime factors of n in increasing order, repeated as many times
+// as they divide n. 1 has no factor. The search stop with the error of ctx
+// when it is done.
+func FactorizeBig(ctx context.Context, n *big.Int) ([]*big.Int, error) {
+	if n.Sign() <= 0 {
+		return nil, errors.New("example3: only positive numbers can be factorized")
+	}
+	factors := []*big.Int{}
+	rest := new(big.Int).Set(n)
+	q, r := new(big.Int), new(big.Int)
+	for _, p := range trialPrimes {
+		bp := new(big.Int).SetUint64(p)
+		if new(big.Int).Mul(bp, bp).Cmp(rest) > 0 {
+			break
+		}
+		for {
+			q.QuoRem(rest, bp, r)
+			if r.Sign() != 0 {
+				break
+			}
+			factors = append(factors, new(big.Int).Set(bp))
+			rest.Set(q)
+		}
+	}
+
+	// The parts left to split
+	parts := []*big.Int{rest}
+	for len(parts) > 0 {
+		part := parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+		if part.Cmp(bigOne) == 0 {
+			continue
+		}
+		if ProbablyPrimeBig(part, 20) {
+			factors = append(factors, part)
+			continue
+		}
+		d, err := findFactor(ctx, part)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, d, new(big.Int).Quo(part, d))
+	}
+	sort.Slice(factors, func(i, j int) bool {
+		return factors[i].Cmp(factors[j]) < 0
+	})
+	return factors, nil
+}
+
+// Return a factor of the composite n, other than 1 and n
+func findFactor(ctx context.Context, n *big.Int) (*big.Int, error) {
+	if n.Bit(0) == 0 {
+		return new(big.Int).Set(bigTwo), nil
+	}
+	if root := perfectPowerRoot(n); root != nil {
+		return root, nil
+	}
+	for c := int64(1); c <= rhoPolynomials; c++ {
+		d, err := pollardBrent(ctx, n, big.NewInt(c), rhoIterations)
+		if d != nil || err != nil {
+			return d, err
+		}
+	}
+	for _, stage := range ecmStages {
+		d, err := ecm(ctx, n, stage.b1, stage.curves)
+		if d != nil || err != nil {
+			return d, err
+		}
+	}
+	return nil, ErrFactorizationFailed
+}
+
+// Return r if n is r^k for some k > 1, nil otherwise. Neither rho nor ECM
+// can split p^k.
+func perfectPowerRoot(n *big.Int) *big.Int {
+	for k := 2; k < n.BitLen(); k++ {
+		root := integerRoot(n, k)
+		if new(big.Int).Exp(root, big.NewInt(int64(k)), nil).Cmp(n) == 0 {
+			return root
+		}
+	}
+	return nil
+}
+
+// Largest r with r^k <= n, by Newton's method starting above the root
+func integerRoot(n *big.Int, k int) *big.Int {
+	bk := big.NewInt(int64(k))
+	x := new(big.Int).Lsh(bigOne, uint((n.BitLen()+k-1)/k))
+	for {
+		// y = ((k-1)x + n/x^(k-1)) / k
+		y := new(big.Int).Exp(x, big.NewInt(int64(k-1)), nil)
+		y.Quo(n, y)
+		y.Add(y, new(big.Int).Mul(x, big.NewInt(int64(k-1))))
+		y.Quo(y, bk)
+		if y.Cmp(x) >= 0 {
+			return x
+		}
+		x = y
+	}
+}
+
+// Pollard's rho with the cycle detection of Brent and the gcd computed for
+// batches of m steps, for the polynomial x^2 + c. Return nil if no factor is
+// found in maxIterations.
+func pollardBrent(ctx context.Context, n, c *big.Int, maxIterations int) (*big.Int, error) {
+	const m = 128
+	f := func(x *big.Int) {
+		x.Mul(x, x)
+		x.Add(x, c)
+		x.Mod(x, n)
+	}
+	y, x, ys := big.NewInt(2), new(big.Int), new(big.Int)
+	q, g, diff := big.NewInt(1), big.NewInt(1), new(big.Int)
+	iterations := 0
+	for r := 1; g.Cmp(bigOne) == 0; r *= 2 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if iterations > maxIterations {
+			return nil, nil
+		}
+		x.Set(y)
+		for i := 0; i < r; i++ {
+			f(y)
+		}
+		for k := 0; k < r && g.Cmp(bigOne) == 0; k += m {
+			ys.Set(y)
+			for i := 0; i < m && i < r-k; i++ {
+				f(y)
+				q.Mul(q, diff.Sub(x, y).Abs(diff))
+				q.Mod(q, n)
+			}
+			g.GCD(nil, nil, q, n)
+			iterations += m
+		}
+		iterations += r
+	}
+	if g.Cmp(n) == 0 {
+		// The batch went past the factor, redo it one step at a time
+		for {
+			f(ys)
+			g.GCD(nil, nil, diff.Sub(x, ys).Abs(diff), n)
+			if g.Cmp(bigOne) > 0 {
+				break
+			}
+		}
+	}
+	if g.Cmp(n) == 0 {
+		return nil, nil
+	}
+	return g, nil
+}
+
+// Lenstra's elliptic curve method, stage 1 only, on Montgomery curves with
+// the parametrization of Suyama. Return nil if none of the curves find a
+// factor with the bound b1.
+func ecm(ctx context.Context, n *big.Int, b1 uint64, curves int) (*big.Int, error) {
+	primes := primesBelow(b1 + 1)
+	for curve := 0; curve < curves; curve++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		sigma := big.NewInt(int64(6 + curve))
+		p, a24, d := suyamaCurve(n, sigma)
+		if d != nil {
+			return d, nil
+		}
+		for i, prime := range primes {
+			if i%1000 == 0 {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+			}
+			// Largest power of the prime below b1
+			k := prime
+			for k <= b1/prime {
+				k *= prime
+			}
+			p = p.multiply(new(big.Int).SetUint64(k), a24, n)
+		}
+		g := new(big.Int).GCD(nil, nil, p.z, n)
+		if g.Cmp(bigOne) > 0 && g.Cmp(n) < 0 {
+			return g, nil
+		}
+	}
+	return nil, nil
+}
+
+// Point of a Montgomery curve in projective coordinates, without y
+type montgomeryPoint struct {
+	x, z *big.Int
+}
+
+// Return the starting point and (A+2)/4 of the curve of Suyama for sigma,
+// or a factor of n if one is found on the way
+func suyamaCurve(n, sigma *big.Int) (montgomeryPoint, *big.Int, *big.Int) {
+	mod := func(x *big.Int) *big.Int { return x.Mod(x, n) }
+	u := mod(new(big.Int).Sub(new(big.Int).Mul(sigma, sigma), big.NewInt(5)))
+	v := mod(new(big.Int).Lsh(sigma, 2))
+	u3 := mod(new(big.Int).Exp(u, big.NewInt(3), n))
+	v3 := mod(new(big.Int).Exp(v, big.NewInt(3), n))
+	// (A+2)/4 = (v-u)^3 (3u+v) / (16 u^3 v)
+	vu := mod(new(big.Int).Sub(v, u))
+	num := mod(new(big.Int).Exp(vu, big.NewInt(3), n))
+	num = mod(num.Mul(num, new(big.Int).Add(new(big.Int).Mul(u, big.NewInt(3)), v)))
+	den := mod(new(big.Int).Mul(new(big.Int).Lsh(u3, 4), v))
+	inv := new(big.Int).ModInverse(den, n)
+	if inv == nil {
+		g := new(big.Int).GCD(nil, nil, den, n)
+		if g.Cmp(bigOne) > 0 && g.Cmp(n) < 0 {
+			return montgomeryPoint{}, nil, g
+		}
+		// sigma give a singular curve, try the next one
+		return montgomeryPoint{x: big.NewInt(0), z: big.NewInt(0)}, big.NewInt(0), nil
+	}
+	return montgomeryPoint{x: u3, z: v3}, mod(num.Mul(num, inv)), nil
+}
+
+// Return k*p with the Montgomery ladder
+func (p montgomeryPoint) multiply(k, a24, n *big.Int) montgomeryPoint {
+	if k.Sign() == 0 {
+		return montgomeryPoint{x: big.NewInt(0), z: big.NewInt(0)}
+	}
+	r0, r1 := p, p.double(a24, n)
+	for i := k.BitLen() - 2; i >= 0; i-- {
+		if k.Bit(i) == 1 {
+			r0, r1 = r0.add(r1, p, n), r1.double(a24, n)
+		} else {
+			r0, r1 = r0.double(a24, n), r0.add(r1, p, n)
+		}
+	}
+	return r0
+}
+
+// Return 2p
+func (p montgomeryPoint) double(a24, n *big.Int) montgomeryPoint {
+	sum := new(big.Int).Add(p.x, p.z)
+	sum.Mul(sum, sum).Mod(sum, n)
+	diff := new(big.Int).Sub(p.x, p.z)
+	diff.Mul(diff, diff).Mod(diff, n)
+	t := new(big.Int).Sub(sum, diff)
+	x := new(big.Int).Mul(sum, diff)
+	z := new(big.Int).Mul(a24, t)
+	z.Add(z, diff).Mul(z, t)
+	return montgomeryPoint{x: x.Mod(x, n), z: z.Mod(z, n)}
+}
+
+// Return p+q knowing p-q
+func (p montgomeryPoint) add(q, pq montgomeryPoint, n *big.Int) montgomeryPoint {
+	u := new(big.Int).Sub(p.x, p.z)
+	u.Mul(u, new(big.Int).Add(q.x, q.z))
+	v := new(big.Int).Add(p.x, p.z)
+	v.Mul(v, new(big.Int).Sub(q.x, q.z))
+	x := new(big.Int).Add(u, v)
+	x.Mul(x, x).Mod(x, n).Mul(x, pq.z)
+	z := new(big.Int).Sub(u, v)
+	z.Mul(z, z).Mod(z, n).Mul(z, pq.x)
+	return montgomeryPoint{x: x.Mod(x, n), z: z.Mod(z, n)}
+}
@@ -0,0 +1,83 @@
+package example3
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func mustBig(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid number " + s)
+	}
+	return n
+}
+
+func TestProbablyPrimeBig(t *testing.T) {
+	tests := []struct {
+		n    string
+		want bool
+	}{
+		{"-7", false},
+		{"0", false},
+		{"25", false},
+		{"18446744073709551557", true},
+		{"170141183460469231731687303715884105727", true},  // 2^127 - 1
+		{"340282366920938463463374607431768211457", false}, // 2^128 + 1
+		{"3317044064679887385961981", false},               // strong pseudoprime to the bases up to 37
+	}
+	for _, test := range tests {
+		if got := ProbablyPrimeBig(mustBig(test.n), 20); got != test.want {
+			t.Errorf("ProbablyPrimeBig(%s) = %v, want %v", test.n, got, test.want)
+		}
+	}
+}
+
+func join(factors []*big.Int) string {
+	list := []string{}
+	for _, f := range factors {
+		list = append(list, f.String())
+	}
+	return strings.Join(list, " ")
+}
+
+func TestFactorizeBig(t *testing.T) {
+	tests := []struct {
+		n    string
+		want string
+	}{
+		{"1", ""},
+		{"97", "97"},
+		{"1000000", "2 2 2 2 2 2 5 5 5 5 5 5"},
+		{"18446744073709551617", "274177 67280421310721"},                                            // 2^64 + 1, rho
+		{"1000006000009", "1000003 1000003"},                                                         // square
+		{"1000000000000000000000000000057", "1000000000000000000000000000057"},                       // prime
+		{"10000000000000370000000013000000000000481", "1000000000000037 10000000000000000000000013"}, // ECM
+		{"12345678901234567890", "2 3 3 5 101 3541 3607 3803 27961"},
+	}
+	for _, test := range tests {
+		factors, err := FactorizeBig(context.Background(), mustBig(test.n))
+		if err != nil {
+			t.Errorf("%s: %v", test.n, err)
+			continue
+		}
+		if got := join(factors); got != test.want {
+			t.Errorf("FactorizeBig(%s) = %s, want %s", test.n, got, test.want)
+		}
+	}
+}
+
+func TestFactorizeBigCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// Product of two primes of 30 and 31 digits, out of reach
+	n := new(big.Int).Mul(mustBig("1000000000000000000000000000057"), mustBig("10000000000000000000000000000033"))
+	if _, err := FactorizeBig(ctx, n); err != context.Canceled {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if _, err := FactorizeBig(context.Background(), big.NewInt(0)); err == nil {
+		t.Error("expected an error for 0")
+	}
+}
@@ -1,3 +1,3 @@
 module github.com/Tanmay-Teaches/golang/chapter3/example3
 
-go 1.14
+go 1.18
@@ -2,23 +2,91 @@ package example3
 
 /*
 Example of how to create an Package in Golang
+
+IsPrime is a deterministic Miller–Rabin test: for every n below 2^64 the
+prime bases up to 37 are known to be enough, so the answer is exact for all
+the integer types. Small numbers are answered by trial division.
 */
 
-func IsPrime(n int) bool {
+import "math/bits"
+
+// Any signed or unsigned integer type
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Primes used for the trial division, also the Miller–Rabin bases up to 37
+var smallPrimes = []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47, 53, 59, 61, 67, 71, 73, 79, 83, 89, 97}
+
+// Bases making Miller–Rabin deterministic below 2^64
+var millerRabinBases = []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37}
+
+// Report if n is prime, negative numbers are not
+func IsPrime[T Integer](n T) bool {
 	if n <= 1 {
 		return false
-	} else if n <= 3 {
+	}
+	return isPrime64(uint64(n))
+}
+
+func isPrime64(n uint64) bool {
+	for _, p := range smallPrimes {
+		if n == p {
+			return true
+		}
+		if n%p == 0 {
+			return false
+		}
+	}
+	// No factor up to 97, so every n below 101*101 is prime
+	if n < 101*101 {
 		return true
-	} else if n%2 == 0 || n%3 == 0 {
-		return false
 	}
 
-	i := 5
-	for i*i < n {
-		if n%i == 0 || n%(i+2) == 0 {
+	// n-1 = d * 2^s with d odd
+	d := n - 1
+	s := bits.TrailingZeros64(d)
+	d >>= uint(s)
+	for _, a := range millerRabinBases {
+		if !strongProbablePrime(n, a, d, s) {
 			return false
 		}
-		i += 6
 	}
 	return true
 }
+
+// Report if n pass the Miller–Rabin round with base a, n-1 = d * 2^s
+func strongProbablePrime(n, a, d uint64, s int) bool {
+	x := powMod(a, d, n)
+	if x == 1 || x == n-1 {
+		return true
+	}
+	for i := 1; i < s; i++ {
+		x = mulMod(x, x, n)
+		if x == n-1 {
+			return true
+		}
+	}
+	return false
+}
+
+// a*b mod m without overflow, the 128 bits product is divided by m
+func mulMod(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return bits.Rem64(hi, lo, m)
+}
+
+// a^e mod m by square and multiply
+func powMod(a, e, m uint64) uint64 {
+	result := uint64(1)
+	a %= m
+	for e > 0 {
+		if e&1 == 1 {
+			result = mulMod(result, a, m)
+		}
+		a = mulMod(a, a, m)
+		e >>= 1
+	}
+	return result
+}
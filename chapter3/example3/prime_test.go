@@ -0,0 +1,61 @@
+package example3
+
+import "testing"
+
+// Trial division up to the square root
+func naivePrime(n int) bool {
+	if n < 2 {
+		return false
+	}
+	for i := 2; i*i <= n; i++ {
+		if n%i == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestIsPrimeSmall(t *testing.T) {
+	for n := -10; n < 200000; n++ {
+		if got, want := IsPrime(n), naivePrime(n); got != want {
+			t.Fatalf("IsPrime(%d) = %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestIsPrimeLarge(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want bool
+	}{
+		{25, false},
+		{49, false},
+		{561, false},                    // Carmichael number
+		{3215031751, false},             // strong pseudoprime to the bases 2, 3, 5 and 7
+		{3825123056546413051, false},    // strong pseudoprime to the bases up to 23
+		{4294967291, true},              // largest prime below 2^32
+		{4294967297, false},             // 641 * 6700417
+		{2305843009213693951, true},     // 2^61 - 1
+		{1000000007 * 998244353, false}, // product of two large primes
+		{18446744073709551557, true},    // largest prime below 2^64
+		{18446744073709551615, false},   // 2^64 - 1
+	}
+	for _, test := range tests {
+		if got := IsPrime(test.n); got != test.want {
+			t.Errorf("IsPrime(%d) = %v, want %v", test.n, got, test.want)
+		}
+	}
+}
+
+func TestIsPrimeTypes(t *testing.T) {
+	type id int32
+	if !IsPrime(int8(127)) || IsPrime(int8(-127)) || !IsPrime(uint8(251)) || IsPrime(uint16(65535)) {
+		t.Error("wrong answer for a small type")
+	}
+	if !IsPrime(int64(9223372036854775783)) || IsPrime(int64(-9223372036854775808)) {
+		t.Error("wrong answer for int64")
+	}
+	if !IsPrime(id(2147483647)) || !IsPrime(uintptr(65521)) {
+		t.Error("wrong answer for a derived type")
+	}
+}
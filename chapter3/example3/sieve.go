@@ -0,0 +1,278 @@
+package example3
+
+/*
+Segmented Sieve of Eratosthenes over odd numbers only.
+
+The range is sieved in segments of segmentSize odd numbers, one bit each so
+a segment fit in 32 KiB of L1 cache. The primes up to the square root of the
+upper bound are found first with a plain sieve, each one keep the next
+multiple to cross out from a segment to the next. The memory is bounded by
+the segment and the base primes: about 80000 base primes for a range ending
+at 1e12.
+
+	it, err := example3.NewPrimeIterator(1e12, 1e12+1000)
+	if err != nil {
+		return err
+	}
+	for it.Next() {
+		fmt.Println(it.Prime())
+	}
+*/
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/bits"
+)
+
+// Number of odd numbers of a segment
+const segmentSize = 1 << 18
+
+// Largest upper bound of the sieve functions, the base primes would not fit
+// in memory far above
+const MaxSieveLimit = 1 << 50
+
+// Error of the sieve functions when hi is above MaxSieveLimit
+var ErrAboveSieveLimit = errors.New("example3: upper bound above MaxSieveLimit")
+
+// State of a segmented sieve of the odd numbers of [lo, hi]
+type sieve struct {
+	hi uint64
+	// Odd primes up to the square root of hi, and the next odd multiple of
+	// each to cross out, 0 until the prime is first used
+	base      []uint64
+	multiples []uint64
+	// Current segment: the odd numbers from start to end, a bit set for composites
+	start, end uint64
+	size       uint64
+	composite  []uint64
+	// First odd number of the next segment
+	next uint64
+}
+
+func newSieve(lo, hi uint64) (*sieve, error) {
+	if hi > MaxSieveLimit {
+		return nil, ErrAboveSieveLimit
+	}
+	if lo < 3 {
+		lo = 3
+	}
+	if lo%2 == 0 {
+		lo++
+	}
+	s := &sieve{hi: hi, next: lo, composite: make([]uint64, segmentSize/64)}
+	s.base = oddPrimesUpTo(isqrt(hi))
+	s.multiples = make([]uint64, len(s.base))
+	return s, nil
+}
+
+// Largest r with r*r <= n
+func isqrt(n uint64) uint64 {
+	r := uint64(math.Sqrt(float64(n)))
+	for r*r > n {
+		r--
+	}
+	for (r+1)*(r+1) <= n {
+		r++
+	}
+	return r
+}
+
+// Odd primes up to n with a plain sieve of the odd numbers
+func oddPrimesUpTo(n uint64) []uint64 {
+	primes := []uint64{}
+	if n < 3 {
+		return primes
+	}
+	// composite[i] is for 2i+1
+	composite := make([]bool, n/2+1)
+	for i := uint64(1); 2*i+1 <= n; i++ {
+		if composite[i] {
+			continue
+		}
+		p := 2*i + 1
+		primes = append(primes, p)
+		for m := p * p; m <= n; m += 2 * p {
+			composite[m/2] = true
+		}
+	}
+	return primes
+}
+
+// Sieve the next segment, return false when the range is done
+func (s *sieve) nextSegment() bool {
+	if s.next > s.hi {
+		return false
+	}
+	s.start = s.next
+	s.end = s.hi
+	if s.end%2 == 0 {
+		s.end--
+	}
+	if s.end-s.start > 2*(segmentSize-1) {
+		s.end = s.start + 2*(segmentSize-1)
+	}
+	s.size = (s.end-s.start)/2 + 1
+	words := s.composite[:(s.size+63)/64]
+	for i := range words {
+		words[i] = 0
+	}
+	for i, p := range s.base {
+		if p*p > s.end {
+			break
+		}
+		m := s.multiples[i]
+		if m == 0 {
+			m = p * p
+			if m < s.start {
+				// First odd multiple in the segment
+				m = (s.start + p - 1) / p * p
+				if m%2 == 0 {
+					m += p
+				}
+			}
+		}
+		for ; m <= s.end; m += 2 * p {
+			j := (m - s.start) / 2
+			words[j/64] |= 1 << (j % 64)
+		}
+		s.multiples[i] = m
+	}
+	s.next = s.end + 2
+	return true
+}
+
+// Bits of the primes of the word i of the current segment
+func (s *sieve) primeBits(i int) uint64 {
+	w := ^s.composite[i]
+	if last := s.size - uint64(i)*64; last < 64 {
+		w &= 1<<last - 1
+	}
+	return w
+}
+
+// Append the primes of the current segment to list
+func (s *sieve) appendPrimes(list []uint64) []uint64 {
+	for i := 0; uint64(i)*64 < s.size; i++ {
+		for w := s.primeBits(i); w != 0; w &= w - 1 {
+			j := uint64(i)*64 + uint64(bits.TrailingZeros64(w))
+			list = append(list, s.start+2*j)
+		}
+	}
+	return list
+}
+
+// Number of primes of the current segment
+func (s *sieve) count() uint64 {
+	n := 0
+	for i := 0; uint64(i)*64 < s.size; i++ {
+		n += bits.OnesCount64(s.primeBits(i))
+	}
+	return uint64(n)
+}
+
+// Return the primes of [lo, hi] in increasing order
+func PrimesInRange(lo, hi uint64) ([]uint64, error) {
+	primes := []uint64{}
+	if lo > hi {
+		return primes, nil
+	}
+	s, err := newSieve(lo, hi)
+	if err != nil {
+		return nil, err
+	}
+	if lo <= 2 && hi >= 2 {
+		primes = append(primes, 2)
+	}
+	for s.nextSegment() {
+		primes = s.appendPrimes(primes)
+	}
+	return primes, nil
+}
+
+// Return the number of primes of [lo, hi] without keeping them
+func CountPrimes(lo, hi uint64) (uint64, error) {
+	if lo > hi {
+		return 0, nil
+	}
+	s, err := newSieve(lo, hi)
+	if err != nil {
+		return 0, err
+	}
+	n := uint64(0)
+	if lo <= 2 && hi >= 2 {
+		n++
+	}
+	for s.nextSegment() {
+		n += s.count()
+	}
+	return n, nil
+}
+
+// Iterate over the primes of a range one segment at a time:
+//
+//	it, err := example3.NewPrimeIterator(lo, hi)
+//	if err != nil {
+//		return err
+//	}
+//	for it.Next() {
+//		fmt.Println(it.Prime())
+//	}
+type PrimeIterator struct {
+	sieve   *sieve
+	primes  []uint64
+	current uint64
+}
+
+// Create an iterator over the primes of [lo, hi]
+func NewPrimeIterator(lo, hi uint64) (*PrimeIterator, error) {
+	s, err := newSieve(lo, hi)
+	if err != nil {
+		return nil, err
+	}
+	it := &PrimeIterator{sieve: s}
+	if lo <= 2 && hi >= 2 {
+		it.primes = append(it.primes, 2)
+	}
+	return it, nil
+}
+
+// Advance to the next prime, return false at the end of the range
+func (it *PrimeIterator) Next() bool {
+	for len(it.primes) == 0 {
+		if !it.sieve.nextSegment() {
+			return false
+		}
+		it.primes = it.sieve.appendPrimes(it.primes[:0])
+	}
+	it.current = it.primes[0]
+	it.primes = it.primes[1:]
+	return true
+}
+
+// The prime found by the last call to Next
+func (it *PrimeIterator) Prime() uint64 {
+	return it.current
+}
+
+// Send the primes of [lo, hi] on the channel, which is closed at the end of
+// the range or when ctx is done
+func PrimesChan(ctx context.Context, lo, hi uint64) (<-chan uint64, error) {
+	it, err := NewPrimeIterator(lo, hi)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan uint64, 64)
+	go func() {
+		defer close(ch)
+		for it.Next() {
+			select {
+			case ch <- it.Prime():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
@@ -0,0 +1,113 @@
+package example3
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// Primes of [lo, hi] found with IsPrime
+func primesByTest(lo, hi uint64) []uint64 {
+	primes := []uint64{}
+	for n := lo; n <= hi; n++ {
+		if IsPrime(n) {
+			primes = append(primes, n)
+		}
+	}
+	return primes
+}
+
+func TestPrimesInRange(t *testing.T) {
+	tests := []struct {
+		lo, hi uint64
+	}{
+		{0, 0}, {0, 1}, {2, 2}, {0, 3}, {4, 4}, {9, 9}, {24, 30}, {10, 5},
+		{0, 100000},
+		// Across segment boundaries
+		{2*segmentSize - 1000, 2*segmentSize + 1000},
+		{1e12, 1e12 + 10000},
+		{MaxSieveLimit - 1000, MaxSieveLimit},
+	}
+	for _, test := range tests {
+		want := primesByTest(test.lo, test.hi)
+		if got, err := PrimesInRange(test.lo, test.hi); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("PrimesInRange(%d, %d) = %v %v, want %v", test.lo, test.hi, got, err, want)
+		}
+		if got, err := CountPrimes(test.lo, test.hi); err != nil || got != uint64(len(want)) {
+			t.Errorf("CountPrimes(%d, %d) = %d %v, want %d", test.lo, test.hi, got, err, len(want))
+		}
+		it, err := NewPrimeIterator(test.lo, test.hi)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []uint64{}
+		for it.Next() {
+			got = append(got, it.Prime())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("PrimeIterator(%d, %d) = %v, want %v", test.lo, test.hi, got, want)
+		}
+	}
+}
+
+func TestAboveSieveLimit(t *testing.T) {
+	for _, hi := range []uint64{MaxSieveLimit + 1, 1 << 60, math.MaxUint64} {
+		if _, err := PrimesInRange(MaxSieveLimit-1000, hi); err != ErrAboveSieveLimit {
+			t.Errorf("PrimesInRange(%d): got %v", hi, err)
+		}
+		if _, err := CountPrimes(0, hi); err != ErrAboveSieveLimit {
+			t.Errorf("CountPrimes(%d): got %v", hi, err)
+		}
+		if _, err := NewPrimeIterator(0, hi); err != ErrAboveSieveLimit {
+			t.Errorf("NewPrimeIterator(%d): got %v", hi, err)
+		}
+		if _, err := PrimesChan(context.Background(), 0, hi); err != ErrAboveSieveLimit {
+			t.Errorf("PrimesChan(%d): got %v", hi, err)
+		}
+	}
+}
+
+func TestCountPrimes(t *testing.T) {
+	counts := map[uint64]uint64{10: 4, 1000: 168, 1e6: 78498, 1e7: 664579}
+	for hi, want := range counts {
+		if got, _ := CountPrimes(0, hi); got != want {
+			t.Errorf("CountPrimes(0, %d) = %d, want %d", hi, got, want)
+		}
+	}
+	// pi(1e12 + 1e6) - pi(1e12), checked with IsPrime
+	lo, hi := uint64(1e12), uint64(1e12+1e6)
+	want := uint64(len(primesByTest(lo, hi)))
+	if got, _ := CountPrimes(lo, hi); got != want {
+		t.Errorf("CountPrimes(%d, %d) = %d, want %d", lo, hi, got, want)
+	}
+}
+
+func TestPrimesChan(t *testing.T) {
+	ch, err := PrimesChan(context.Background(), 90, 130)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []uint64{}
+	for p := range ch {
+		got = append(got, p)
+	}
+	if want := []uint64{97, 101, 103, 107, 109, 113, 127}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err = PrimesChan(ctx, 0, MaxSieveLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ch
+	cancel()
+	n := 0
+	for range ch {
+		n++
+	}
+	if n > 100 {
+		t.Errorf("got %d primes after cancel", n)
+	}
+}
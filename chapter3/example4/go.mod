@@ -1,8 +1,22 @@
 module example4
 
-go 1.14
+go 1.18
 
 require (
 	github.com/Tanmay-Teaches/golang/chapter3/example3 v0.0.0-20200817005752-e03d213ecc87
 	github.com/labstack/echo/v4 v4.1.16
 )
+
+require (
+	github.com/labstack/gommon v0.3.0 // indirect
+	github.com/mattn/go-colorable v0.1.6 // indirect
+	github.com/mattn/go-isatty v0.0.12 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasttemplate v1.1.0 // indirect
+	golang.org/x/crypto v0.0.0-20200221231518-2aa609cf4a9d // indirect
+	golang.org/x/net v0.0.0-20200226121028-0de0cce0169b // indirect
+	golang.org/x/sys v0.0.0-20200223170610-d5e6a3e2c0ae // indirect
+	golang.org/x/text v0.3.2 // indirect
+)
+
+replace github.com/Tanmay-Teaches/golang/chapter3/example3 => ../example3
@@ -1,7 +1,8 @@
 package main
 
 /*
-Create a simple API endpoint which return true if number is prime
+Create a simple API endpoint which return true if number is prime, and one
+returning its prime factors: GET /:number and GET /:number/factors
 
 To use private repository or private git like github enterprise use ssh keys over username/password.
 	The following command change all URL from https to git which use ssh keys
@@ -12,23 +13,60 @@ To use private repository or private git like github enterprise use ssh keys ove
 		export GONOSUMDB=github.com/Tanmay-Teaches/golang
 */
 import (
+	"context"
+	"errors"
 	"net/http"
 	"github.com/Tanmay-Teaches/golang/chapter3/example3"
 	"github.com/labstack/echo/v4"
+	"math/big"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// Time given to the factorization of one number
+const factorTimeout = 10 * time.Second
+
+// Status logged when the client went away before the answer, as nginx does
+const statusClientClosedRequest = 499
+
 
 func main() {
 
 	e := echo.New()
 	e.GET("/:number", func(c echo.Context) error {
-		nstr := c.Param("number")
-		n, err := strconv.Atoi(nstr)
-		if err != nil {
-			return c.String(http.StatusBadRequest, err.Error())
+		n, ok := new(big.Int).SetString(c.Param("number"), 10)
+		if !ok {
+			return c.String(http.StatusBadRequest, "not a number: "+c.Param("number"))
+		}
+		// Exact below 2^64, probabilistic above
+		if n.IsUint64() {
+			return c.String(http.StatusOK, strconv.FormatBool(example3.IsPrime(n.Uint64())))
+		}
+		return c.String(http.StatusOK, strconv.FormatBool(example3.ProbablyPrimeBig(n, 20)))
+	})
+	// Prime factors separated by spaces, the search is stopped after factorTimeout
+	e.GET("/:number/factors", func(c echo.Context) error {
+		n, ok := new(big.Int).SetString(c.Param("number"), 10)
+		if !ok || n.Sign() <= 0 {
+			return c.String(http.StatusBadRequest, "not a positive number: "+c.Param("number"))
+		}
+		ctx, cancel := context.WithTimeout(c.Request().Context(), factorTimeout)
+		defer cancel()
+		factors, err := example3.FactorizeBig(ctx, n)
+		if errors.Is(err, context.Canceled) {
+			// Nobody is left to read the answer
+			return c.NoContent(statusClientClosedRequest)
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			return c.String(http.StatusGatewayTimeout, "no factorization found in "+factorTimeout.String())
+		} else if err != nil {
+			return c.String(http.StatusUnprocessableEntity, err.Error())
+		}
+		list := []string{}
+		for _, f := range factors {
+			list = append(list, f.String())
 		}
-		return c.String(http.StatusOK, strconv.FormatBool(example3.IsPrime(n)))
+		return c.String(http.StatusOK, strings.Join(list, " "))
 	})
 
 	e.Logger.Fatal(e.Start(":1323"))